@@ -0,0 +1,192 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/afero"
+	"golang.org/x/crypto/ssh"
+)
+
+// fileState identifies the version of a file that was parsed. A file whose
+// state did not change since it was parsed is served from the cache. The zero
+// state stands for a file that does not exist.
+type fileState struct {
+	inode   uint64
+	modTime int64
+	size    int64
+}
+
+// parsedFile is what was read from a file below a cert path.
+type parsedFile struct {
+	certs []certificate
+	crls  []*x509.RevocationList
+	// dependencies are the files read along with this one, like the
+	// certificates referenced by a kubeconfig or the password file of a
+	// PKCS#12 bundle, and their state at the time.
+	dependencies    map[string]fileState
+	encoding        string
+	kubeconfigCerts []kubeconfigCert
+	openPGPKeys     []openPGPKey
+	// privateKeys are the public halves of the private keys found, nil for
+	// keys that could not be read.
+	privateKeys []crypto.PublicKey
+	sshCerts    []*ssh.Certificate
+}
+
+type cachedFile struct {
+	parsed parsedFile
+	state  fileState
+}
+
+func stateOf(info os.FileInfo) fileState {
+	return fileState{
+		inode:   inodeOf(info),
+		modTime: info.ModTime().UnixNano(),
+		size:    info.Size(),
+	}
+}
+
+// stateAt returns the state of the file at the given path, or the zero state
+// if it does not exist, so a file appearing later counts as a change.
+func (e *Exporter) stateAt(path string) fileState {
+	info, err := e.fs.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+
+	return stateOf(info)
+}
+
+// needsWalk returns true if the given cert path has to be walked to find out
+// about changes. Without a watcher that is the case on every scrape.
+func (e *Exporter) needsWalk(path string) bool {
+	if e.watcher == nil {
+		return true
+	}
+
+	e.walkedMutex.Lock()
+	defer e.walkedMutex.Unlock()
+
+	return !e.walked[path]
+}
+
+// walkPath walks the given cert path and updates its cached files, parsing
+// only the files that are new or changed since the last walk.
+func (e *Exporter) walkPath(path string) error {
+	if e.watcher != nil {
+		// Marked before walking, so changes made during the walk cause
+		// another one on the next scrape.
+		e.setWalked(path, true)
+	}
+
+	if e.cache == nil {
+		e.cache = map[string]map[string]cachedFile{}
+	}
+	previous := e.cache[path]
+	files := map[string]cachedFile{}
+
+	filter := e.filterFor(path)
+	err := afero.Walk(e.fs, path, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("%s could not be walked: %s", fpath, microerror.Mask(err)))
+			return nil
+		}
+
+		rel, err := filepath.Rel(path, fpath)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if info.IsDir() {
+			if fpath != path && filter.skipsDir(rel) {
+				e.logger.Log("debug", fmt.Sprintf("skipping cert folder %s", fpath))
+				return filepath.SkipDir
+			}
+
+			e.watchDir(path, fpath)
+			return nil
+		}
+
+		if !filter.includes(rel) {
+			e.logger.Log("debug", fmt.Sprintf("skipping %s not matching the filter of %s", fpath, path))
+			return nil
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			// Follow symlinks, so swapping their target, like Kubernetes does
+			// for mounted secrets, invalidates the cached file.
+			info, err = e.fs.Stat(fpath)
+			if err != nil {
+				e.logger.Log("warning", fmt.Sprintf("%s could not be followed: %s", fpath, microerror.Mask(err)))
+				return nil
+			}
+
+			e.watchSymlinkTarget(path, fpath)
+		}
+
+		// FIFOs, sockets and devices, found when broad roots are walked,
+		// block or never end when read.
+		if !info.Mode().IsRegular() {
+			e.logger.Log("debug", fmt.Sprintf("skipping %s which is not a regular file", fpath))
+			return nil
+		}
+
+		if filter.MaxFileSize > 0 && info.Size() > filter.MaxFileSize {
+			e.logger.Log("debug", fmt.Sprintf("skipping %s larger than %d bytes", fpath, filter.MaxFileSize))
+			return nil
+		}
+
+		state := stateOf(info)
+		cached, ok := previous[fpath]
+		if ok && cached.state == state && e.dependenciesUnchanged(cached.parsed.dependencies) {
+			e.logger.Log("debug", fmt.Sprintf("using cached cert %s", fpath))
+			files[fpath] = cached
+			return nil
+		}
+
+		e.logger.Log("debug", fmt.Sprintf("checking cert %s", fpath))
+		file, err := afero.ReadFile(e.fs, fpath)
+		if err != nil {
+			e.logger.Log("error", microerror.Mask(err))
+			return nil
+		}
+
+		parsed := e.parseFile(fpath, file)
+		for dependency := range parsed.dependencies {
+			e.watchDependency(path, dependency)
+		}
+
+		files[fpath] = cachedFile{
+			parsed: parsed,
+			state:  state,
+		}
+
+		return nil
+	})
+	if err != nil {
+		e.setWalked(path, false)
+		return microerror.Mask(err)
+	}
+
+	e.cache[path] = files
+
+	return nil
+}
+
+// cachedPaths returns the paths of the cached files below the given cert
+// path in lexical order, so metrics are emitted in a stable order.
+func (e *Exporter) cachedPaths(path string) []string {
+	var paths []string
+	for fpath := range e.cache[path] {
+		paths = append(paths, fpath)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
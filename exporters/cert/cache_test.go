@@ -0,0 +1,200 @@
+package cert
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/afero"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// scrape renders the metrics of the given exporter like a Prometheus scrape.
+func scrape(t *testing.T, e *Exporter) string {
+	t.Helper()
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(e); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	return body
+}
+
+// TestCollectPath_Cache makes sure files are parsed once and served from the
+// cache until their modification time or size changes.
+func TestCollectPath_Cache(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	cert, _ := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+	certPEM := generateSelfSignedCertPEM(t, time.Now().Add(48*time.Hour))
+	modTime := time.Now().Add(-1 * time.Hour)
+
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/tls.crt", certPEM, 0644)
+	_ = fs.Chtimes("/certs/tls.crt", modTime, modTime)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+
+	if got := len(samplesFor(scrape(t, e), "/certs/tls.crt")); got != 1 {
+		t.Fatalf("expected 1 sample, got %d", got)
+	}
+
+	// Same size and modification time: the cached certificate is served.
+	_ = afero.WriteFile(fs, "/certs/tls.crt", bytes.Repeat([]byte("x"), len(certPEM)), 0644)
+	_ = fs.Chtimes("/certs/tls.crt", modTime, modTime)
+
+	if got := len(samplesFor(scrape(t, e), "/certs/tls.crt")); got != 1 {
+		t.Fatalf("expected the cached certificate to be served, got %d samples", got)
+	}
+
+	// A new certificate: the file is parsed again.
+	_ = afero.WriteFile(fs, "/certs/tls.crt", generateSelfSignedCertPEM(t, cert.NotAfter), 0644)
+
+	samples := samplesFor(scrape(t, e), "/certs/tls.crt")
+	if len(samples) != 1 || !strings.Contains(samples[0], fmt.Sprintf(`serialnumber="%x"`, cert.SerialNumber)) {
+		t.Fatalf("expected the changed certificate to be served, got %v", samples)
+	}
+
+	// A removed file: it is dropped from the cache.
+	_ = fs.Remove("/certs/tls.crt")
+
+	if got := len(samplesFor(scrape(t, e), "/certs/tls.crt")); got != 0 {
+		t.Fatalf("expected the removed certificate to be dropped, got %d samples", got)
+	}
+}
+
+// TestCollectPath_PKCS12PasswordFile makes sure a PKCS#12 bundle is opened
+// again once its sibling password file appears or changes, even though the
+// bundle itself did not change.
+func TestCollectPath_PKCS12PasswordFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	leaf, key := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+	bundle, err := pkcs12.Modern.Encode(key, leaf, nil, "sibling")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/keystore.p12", bundle, 0644)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+
+	if got := len(samplesFor(scrape(t, e), "/certs/keystore.p12")); got != 0 {
+		t.Fatalf("expected the keystore not to be opened without its password, got %d samples", got)
+	}
+
+	_ = afero.WriteFile(fs, "/certs/keystore.p12.pass", []byte("wrong\n"), 0644)
+
+	if got := len(samplesFor(scrape(t, e), "/certs/keystore.p12")); got != 0 {
+		t.Fatalf("expected the keystore not to be opened with a wrong password, got %d samples", got)
+	}
+
+	_ = afero.WriteFile(fs, "/certs/keystore.p12.pass", []byte("sibling\n"), 0644)
+
+	if got := len(samplesFor(scrape(t, e), "/certs/keystore.p12")); got != 1 {
+		t.Fatalf("expected the keystore to be opened with its new password, got %d samples", got)
+	}
+}
+
+// TestCollectPath_Watch makes sure a watched cert path is only walked again
+// after inotify reported a change below it.
+func TestCollectPath_Watch(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "ca.crt"), generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour)), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := newTestExporter(t, afero.NewOsFs(), []string{dir})
+	if err := e.watch(); err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close() // nolint:errcheck
+
+	body := scrape(t, e)
+	if got := len(samplesFor(body, filepath.Join(dir, "ca.crt"))); got != 1 {
+		t.Fatalf("expected 1 sample, got %d", got)
+	}
+	if e.needsWalk(dir) {
+		t.Fatal("expected the unchanged cert path to be served from the cache")
+	}
+
+	err = os.MkdirAll(filepath.Join(dir, "etcd"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "etcd", "ca.crt"), generateSelfSignedCertPEM(t, time.Now().Add(48*time.Hour)), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !e.needsWalk(dir) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the change to be reported by inotify")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	body = scrape(t, e)
+	if got := len(samplesFor(body, filepath.Join(dir, "etcd", "ca.crt"))); got != 1 {
+		t.Fatalf("expected the new certificate to be served, got %d samples", got)
+	}
+}
+
+// TestCollectPath_WatchSymlinkTarget makes sure changing the target of a
+// symlink outside of the watched cert path invalidates it.
+func TestCollectPath_WatchSymlinkTarget(t *testing.T) {
+	dir := t.TempDir()
+	shared := t.TempDir()
+
+	target := filepath.Join(shared, "ca.crt")
+	err := os.WriteFile(target, generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour)), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Symlink(target, filepath.Join(dir, "ca.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := newTestExporter(t, afero.NewOsFs(), []string{dir})
+	if err := e.watch(); err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close() // nolint:errcheck
+
+	scrape(t, e)
+	if e.needsWalk(dir) {
+		t.Fatal("expected the unchanged cert path to be served from the cache")
+	}
+
+	renewed, _ := generateSelfSignedCert(t, time.Now().Add(48*time.Hour))
+	err = os.WriteFile(target, encodeCertsPEM(renewed), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !e.needsWalk(dir) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the change of the symlink target to be reported by inotify")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	body := scrape(t, e)
+	if !strings.Contains(body, fmt.Sprintf(`serialnumber="%x"`, renewed.SerialNumber)) {
+		t.Fatalf("expected the renewed certificate to be served in\n%s", body)
+	}
+}
@@ -0,0 +1,48 @@
+//go:build unix
+
+package cert
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// TestCollectPath_NonRegularFiles makes sure FIFOs, which block forever when
+// read, are skipped instead of hanging every scrape.
+func TestCollectPath_NonRegularFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "tls.crt"), generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour)), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = syscall.Mkfifo(filepath.Join(dir, "fifo.crt"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Symlink(filepath.Join(dir, "fifo.crt"), filepath.Join(dir, "link.crt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := newTestExporter(t, afero.NewOsFs(), []string{dir})
+
+	done := make(chan string)
+	go func() {
+		done <- scrape(t, e)
+	}()
+
+	select {
+	case body := <-done:
+		if got := len(samplesFor(body, filepath.Join(dir, "tls.crt"))); got != 1 {
+			t.Fatalf("expected 1 sample for the regular file, got %d in\n%s", got, body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("scrape blocked on a FIFO")
+	}
+}
@@ -0,0 +1,85 @@
+package cert
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/cert-exporter/pkg/chain"
+)
+
+// exportChains verifies the chain of every leaf certificate below the given
+// cert path against the CA certificates found below it. Leaves whose issuer is
+// not among them are not reported on.
+func (e *Exporter) exportChains(ch chan<- prometheus.Metric, path string) {
+	candidates := e.cachedCerts(path)
+
+	now := time.Now()
+	for _, fpath := range e.cachedPaths(path) {
+		for _, c := range e.cache[path][fpath].parsed.certs {
+			if !chain.IsLeaf(c.cert) {
+				continue
+			}
+
+			result, ok := chain.Verify(c.cert, candidates, now)
+			if !ok {
+				continue
+			}
+
+			serialNumber := fmt.Sprintf("%x", c.cert.SerialNumber)
+			valid := 0.0
+			if result.Valid() {
+				valid = 1
+			} else {
+				e.logger.Log("warning", fmt.Sprintf("certificate %s (%s) does not chain to its issuer: %s", fpath, serialNumber, result.Err))
+			}
+
+			ch <- prometheus.MustNewConstMetric(e.chainValid, prometheus.GaugeValue, valid, fpath, serialNumber, c.alias)
+			ch <- prometheus.MustNewConstMetric(e.chainNotAfter, prometheus.GaugeValue, float64(result.NotAfter().Unix()), fpath, serialNumber, c.alias)
+		}
+	}
+}
+
+// cachedCerts returns all certificates found below the given cert path.
+func (e *Exporter) cachedCerts(path string) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for _, fpath := range e.cachedPaths(path) {
+		for _, c := range e.cache[path][fpath].parsed.certs {
+			certs = append(certs, c.cert)
+		}
+	}
+
+	return certs
+}
+
+// newChainValidDesc describes whether the chain of a leaf certificate verifies
+// against the CA certificates found next to it.
+func newChainValidDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "", "chain_valid"),
+		"Whether the cert chains to its issuer found next to it, 1 if it does and 0 otherwise.",
+		[]string{
+			"path",
+			"serialnumber",
+			"alias",
+		},
+		nil,
+	)
+}
+
+// newChainNotAfterDesc describes the earliest expiry of any certificate in the
+// chain of a leaf, which is when the leaf stops being usable.
+func newChainNotAfterDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "", "chain_not_after"),
+		"Timestamp after which any cert in the chain of the cert is invalid.",
+		[]string{
+			"path",
+			"serialnumber",
+			"alias",
+		},
+		nil,
+	)
+}
@@ -0,0 +1,60 @@
+package cert
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/giantswarm/cert-exporter/pkg/certtest"
+)
+
+func encodeCertsPEM(certs ...*x509.Certificate) []byte {
+	var data []byte
+	for _, cert := range certs {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+
+	return data
+}
+
+// TestScrape_Chain covers a leaf chaining to the CA next to it and one issued
+// by a CA that was since rotated under the same name.
+func TestScrape_Chain(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	ca, caKey := certtest.Generate(t, "etcd-ca", true, time.Now().Add(10*365*24*time.Hour), nil, nil)
+	oldCA, oldCAKey := certtest.Generate(t, "etcd-ca", true, time.Now().Add(365*24*time.Hour), nil, nil)
+	server, _ := certtest.Generate(t, "etcd-server", false, time.Now().Add(30*24*time.Hour), ca, caKey)
+	peer, _ := certtest.Generate(t, "etcd-peer", false, time.Now().Add(60*24*time.Hour), oldCA, oldCAKey)
+	unrelated, _ := certtest.Generate(t, "unrelated", false, time.Now().Add(24*time.Hour), nil, nil)
+
+	_ = fs.MkdirAll("/etc/etcd", 0755)
+	_ = afero.WriteFile(fs, "/etc/etcd/ca.crt", encodeCertsPEM(ca), 0644)
+	_ = afero.WriteFile(fs, "/etc/etcd/server.crt", encodeCertsPEM(server), 0644)
+	_ = afero.WriteFile(fs, "/etc/etcd/peer.crt", encodeCertsPEM(peer), 0644)
+	_ = afero.WriteFile(fs, "/etc/etcd/unrelated.crt", encodeCertsPEM(unrelated), 0644)
+
+	e := newTestExporter(t, fs, []string{"/etc/etcd"})
+
+	body := scrape(t, e)
+
+	expected := []string{
+		fmt.Sprintf(`cert_exporter_chain_valid{alias="",path="/etc/etcd/server.crt",serialnumber="%x"} 1`, server.SerialNumber),
+		fmt.Sprintf(`cert_exporter_chain_valid{alias="",path="/etc/etcd/peer.crt",serialnumber="%x"} 0`, peer.SerialNumber),
+		fmt.Sprintf(`cert_exporter_chain_not_after{alias="",path="/etc/etcd/server.crt",serialnumber="%x"} %v`, server.SerialNumber, float64(server.NotAfter.Unix())),
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in\n%s", sample, body)
+		}
+	}
+
+	if strings.Contains(body, `cert_exporter_chain_valid{alias="",path="/etc/etcd/unrelated.crt"`) {
+		t.Error("expected no chain to be reported for a certificate whose issuer is not next to it")
+	}
+}
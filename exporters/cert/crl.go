@@ -0,0 +1,167 @@
+package cert
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/cert-exporter/pkg/revocation"
+)
+
+// parseCRLs returns the certificate revocation lists held by the given file
+// contents, either as X509 CRL blocks or as a binary DER file (.crl).
+func (e *Exporter) parseCRLs(fpath string, file []byte) []*x509.RevocationList {
+	crls, err := revocation.ParseCRLs(file)
+	if err != nil {
+		e.logger.Log("warning", fmt.Sprintf("%s could not be parsed as a CRL: %s", fpath, microerror.Mask(err)))
+	}
+
+	return crls
+}
+
+// fileIsDERCRL returns true if the given binary DER file contents are a
+// certificate revocation list rather than a certificate.
+func fileIsDERCRL(f []byte) bool {
+	_, err := x509.ParseRevocationList(f)
+	return err == nil
+}
+
+// exportCRLs reports the newest CRL of each issuer found in the given file.
+// Files replaced in place sometimes hold the previous CRL next to the new one,
+// which would otherwise collide into the same series.
+func (e *Exporter) exportCRLs(ch chan<- prometheus.Metric, fpath string, crls []*x509.RevocationList) {
+	for _, crl := range newestCRLs(crls) {
+		issuer := crl.Issuer.String()
+		ch <- prometheus.MustNewConstMetric(e.crlThisUpdate, prometheus.GaugeValue, float64(crl.ThisUpdate.Unix()), fpath, issuer)
+		// NextUpdate is optional, CRLs without one never go stale.
+		if !crl.NextUpdate.IsZero() {
+			ch <- prometheus.MustNewConstMetric(e.crlNextUpdate, prometheus.GaugeValue, float64(crl.NextUpdate.Unix()), fpath, issuer)
+		}
+		ch <- prometheus.MustNewConstMetric(e.crlRevoked, prometheus.GaugeValue, float64(len(crl.RevokedCertificateEntries)), fpath, issuer)
+	}
+}
+
+// newestCRLs returns the newest CRL of each issuer, by this update and then
+// CRL number, in the order the issuers were first found.
+func newestCRLs(crls []*x509.RevocationList) []*x509.RevocationList {
+	var newest []*x509.RevocationList
+	byIssuer := map[string]int{}
+	for _, crl := range crls {
+		i, ok := byIssuer[crl.Issuer.String()]
+		if !ok {
+			byIssuer[crl.Issuer.String()] = len(newest)
+			newest = append(newest, crl)
+			continue
+		}
+
+		if isNewerCRL(crl, newest[i]) {
+			newest[i] = crl
+		}
+	}
+
+	return newest
+}
+
+func isNewerCRL(crl, other *x509.RevocationList) bool {
+	if !crl.ThisUpdate.Equal(other.ThisUpdate) {
+		return crl.ThisUpdate.After(other.ThisUpdate)
+	}
+
+	return crl.Number != nil && other.Number != nil && crl.Number.Cmp(other.Number) > 0
+}
+
+// exportRevoked reports whether the certificates below the given cert paths
+// were revoked by a CRL found below any of them. Certificates whose issuer
+// has no CRL there are not reported on.
+func (e *Exporter) exportRevoked(ch chan<- prometheus.Metric, paths []string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var crls []*x509.RevocationList
+	for _, path := range paths {
+		for _, cached := range e.cache[path] {
+			crls = append(crls, cached.parsed.crls...)
+		}
+	}
+	if len(crls) == 0 {
+		return
+	}
+
+	index := revocation.NewIndex(crls)
+	for _, path := range paths {
+		for _, fpath := range e.cachedPaths(path) {
+			for _, c := range e.cache[path][fpath].parsed.certs {
+				revoked, ok := index.Status(c.cert)
+				if !ok {
+					continue
+				}
+
+				serialNumber := fmt.Sprintf("%x", c.cert.SerialNumber)
+				value := 0.0
+				if revoked {
+					value = 1
+					e.logger.Log("warning", fmt.Sprintf("certificate %s (%s) is revoked", fpath, serialNumber))
+				}
+
+				ch <- prometheus.MustNewConstMetric(e.revoked, prometheus.GaugeValue, value, fpath, serialNumber, c.alias)
+			}
+		}
+	}
+}
+
+// newRevokedDesc describes whether a certificate was revoked by a CRL of its
+// issuer. It shares the labels of the not after metric.
+func newRevokedDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "", "revoked"),
+		"Whether the cert is revoked by a CRL of its issuer, 1 if it is and 0 otherwise.",
+		[]string{
+			"path",
+			"serialnumber",
+			"alias",
+		},
+		nil,
+	)
+}
+
+// newCRLNextUpdateDesc describes when a CRL is due to be replaced. Clients
+// reject it past that point, which breaks mTLS relying on it.
+func newCRLNextUpdateDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "crl", "next_update"),
+		"Timestamp after which the CRL is stale.",
+		[]string{
+			"path",
+			"issuer",
+		},
+		nil,
+	)
+}
+
+// newCRLThisUpdateDesc describes when a CRL was issued.
+func newCRLThisUpdateDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "crl", "this_update"),
+		"Timestamp at which the CRL was issued.",
+		[]string{
+			"path",
+			"issuer",
+		},
+		nil,
+	)
+}
+
+// newCRLRevokedDesc describes the number of certificates a CRL revokes.
+func newCRLRevokedDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "crl", "revoked_certificates"),
+		"Number of certs revoked by the CRL.",
+		[]string{
+			"path",
+			"issuer",
+		},
+		nil,
+	)
+}
@@ -0,0 +1,164 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/giantswarm/cert-exporter/pkg/certtest"
+)
+
+func generateCRL(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, nextUpdate time.Time, revoked ...*big.Int) *x509.RevocationList {
+	t.Helper()
+
+	return generateNumberedCRL(t, ca, caKey, 1, time.Now().Add(-1*time.Hour), nextUpdate, revoked...)
+}
+
+func generateNumberedCRL(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, number int64, thisUpdate, nextUpdate time.Time, revoked ...*big.Int) *x509.RevocationList {
+	t.Helper()
+
+	template := &x509.RevocationList{
+		Number:     big.NewInt(number),
+		ThisUpdate: thisUpdate.Truncate(time.Second),
+		NextUpdate: nextUpdate.Truncate(time.Second),
+	}
+	for _, serial := range revoked {
+		template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   serial,
+			RevocationTime: time.Now().Add(-1 * time.Hour),
+		})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, template, ca, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return crl
+}
+
+func TestScrape_CRL(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	ca, caKey := certtest.Generate(t, "ingress-ca", true, time.Now().Add(365*24*time.Hour), nil, nil)
+	crl := generateCRL(t, ca, caKey, time.Now().Add(24*time.Hour), big.NewInt(1), big.NewInt(2))
+	expired := generateCRL(t, ca, caKey, time.Now().Add(-1*time.Minute))
+
+	_ = fs.MkdirAll("/etc/ssl", 0755)
+	// The CRL distributed next to the CA, and both in one bundle.
+	_ = afero.WriteFile(fs, "/etc/ssl/ca.crl.pem", pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl.Raw}), 0644)
+	_ = afero.WriteFile(fs, "/etc/ssl/bundle.pem", append(encodeCertsPEM(ca), pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: expired.Raw})...), 0644)
+	_ = afero.WriteFile(fs, "/etc/ssl/ca.crl", crl.Raw, 0644)
+
+	e := newTestExporter(t, fs, []string{"/etc/ssl"})
+
+	body := scrape(t, e)
+
+	issuer := "CN=ingress-ca"
+	expected := []string{
+		fmt.Sprintf(`cert_exporter_crl_next_update{issuer="%s",path="/etc/ssl/ca.crl.pem"} %v`, issuer, float64(crl.NextUpdate.Unix())),
+		fmt.Sprintf(`cert_exporter_crl_this_update{issuer="%s",path="/etc/ssl/ca.crl.pem"} %v`, issuer, float64(crl.ThisUpdate.Unix())),
+		fmt.Sprintf(`cert_exporter_crl_revoked_certificates{issuer="%s",path="/etc/ssl/ca.crl.pem"} 2`, issuer),
+		fmt.Sprintf(`cert_exporter_crl_next_update{issuer="%s",path="/etc/ssl/ca.crl"} %v`, issuer, float64(crl.NextUpdate.Unix())),
+		fmt.Sprintf(`cert_exporter_crl_next_update{issuer="%s",path="/etc/ssl/bundle.pem"} %v`, issuer, float64(expired.NextUpdate.Unix())),
+		fmt.Sprintf(`cert_exporter_crl_revoked_certificates{issuer="%s",path="/etc/ssl/bundle.pem"} 0`, issuer),
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in\n%s", sample, body)
+		}
+	}
+
+	// The CA in the bundle is still exported, the CRL files export no cert.
+	if got := len(samplesFor(body, "/etc/ssl/bundle.pem")); got != 1 {
+		t.Errorf("expected 1 certificate sample for the bundle, got %d", got)
+	}
+	for _, fpath := range []string{"/etc/ssl/ca.crl.pem", "/etc/ssl/ca.crl"} {
+		if got := len(samplesFor(body, fpath)); got != 0 {
+			t.Errorf("expected no certificate samples for %s, got %d", fpath, got)
+		}
+	}
+}
+
+// TestScrape_CRLsOfSameIssuer covers a file holding the previous CRL of an
+// issuer next to the current one, of which only the current one is reported.
+func TestScrape_CRLsOfSameIssuer(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	ca, caKey := certtest.Generate(t, "ingress-ca", true, time.Now().Add(365*24*time.Hour), nil, nil)
+	previous := generateNumberedCRL(t, ca, caKey, 1, time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+	current := generateNumberedCRL(t, ca, caKey, 2, time.Now().Add(-1*time.Hour), time.Now().Add(24*time.Hour), big.NewInt(1))
+
+	file := append(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: current.Raw}), pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: previous.Raw})...)
+
+	_ = fs.MkdirAll("/etc/ssl", 0755)
+	_ = afero.WriteFile(fs, "/etc/ssl/ca.crl.pem", file, 0644)
+
+	e := newTestExporter(t, fs, []string{"/etc/ssl"})
+
+	body := scrape(t, e)
+
+	expected := []string{
+		fmt.Sprintf(`cert_exporter_crl_next_update{issuer="CN=ingress-ca",path="/etc/ssl/ca.crl.pem"} %v`, float64(current.NextUpdate.Unix())),
+		fmt.Sprintf(`cert_exporter_crl_this_update{issuer="CN=ingress-ca",path="/etc/ssl/ca.crl.pem"} %v`, float64(current.ThisUpdate.Unix())),
+		`cert_exporter_crl_revoked_certificates{issuer="CN=ingress-ca",path="/etc/ssl/ca.crl.pem"} 1`,
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in\n%s", sample, body)
+		}
+	}
+	if got := strings.Count(body, "cert_exporter_crl_next_update{"); got != 1 {
+		t.Errorf("expected 1 CRL to be reported, got %d in\n%s", got, body)
+	}
+}
+
+// TestScrape_Revoked covers a CRL kept in another cert path than the
+// certificates it revokes.
+func TestScrape_Revoked(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	ca, caKey := certtest.Generate(t, "kubelet-ca", true, time.Now().Add(365*24*time.Hour), nil, nil)
+	revoked, _ := certtest.Generate(t, "revoked", false, time.Now().Add(24*time.Hour), ca, caKey)
+	good, _ := certtest.Generate(t, "good", false, time.Now().Add(48*time.Hour), ca, caKey)
+	other, _ := certtest.Generate(t, "other", false, time.Now().Add(72*time.Hour), nil, nil)
+	crl := generateCRL(t, ca, caKey, time.Now().Add(24*time.Hour), revoked.SerialNumber)
+
+	_ = fs.MkdirAll("/var/lib/kubelet/pki", 0755)
+	_ = fs.MkdirAll("/etc/crl", 0755)
+	_ = afero.WriteFile(fs, "/var/lib/kubelet/pki/revoked.crt", encodeCertsPEM(revoked), 0644)
+	_ = afero.WriteFile(fs, "/var/lib/kubelet/pki/good.crt", encodeCertsPEM(good), 0644)
+	_ = afero.WriteFile(fs, "/var/lib/kubelet/pki/other.crt", encodeCertsPEM(other), 0644)
+	_ = afero.WriteFile(fs, "/etc/crl/kubelet-ca.crl", crl.Raw, 0644)
+
+	e := newTestExporter(t, fs, []string{"/var/lib/kubelet/pki", "/etc/crl"})
+
+	body := scrape(t, e)
+
+	expected := []string{
+		fmt.Sprintf(`cert_exporter_revoked{alias="",path="/var/lib/kubelet/pki/revoked.crt",serialnumber="%x"} 1`, revoked.SerialNumber),
+		fmt.Sprintf(`cert_exporter_revoked{alias="",path="/var/lib/kubelet/pki/good.crt",serialnumber="%x"} 0`, good.SerialNumber),
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in\n%s", sample, body)
+		}
+	}
+
+	if strings.Contains(body, `cert_exporter_revoked{alias="",path="/var/lib/kubelet/pki/other.crt"`) {
+		t.Error("expected no revocation status for a certificate of an unknown issuer")
+	}
+}
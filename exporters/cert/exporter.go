@@ -1,28 +1,128 @@
 package cert
 
 import (
+	"crypto"
 	"crypto/x509"
+	"encoding/asn1"
 	"encoding/pem"
 	"fmt"
-	"os"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/afero"
+
+	"github.com/giantswarm/cert-exporter/pkg/certinfo"
+	"github.com/giantswarm/cert-exporter/pkg/keystore"
+	"github.com/giantswarm/cert-exporter/pkg/policy"
+	"github.com/giantswarm/cert-exporter/pkg/revocation"
+)
+
+// Encodings a certificate file can be found in, logged per file so operators
+// can tell which format each file was read as.
+const (
+	encodingDER        = "DER"
+	encodingJKS        = "JKS"
+	encodingKubeconfig = "kubeconfig"
+	encodingPEM        = "PEM"
+	encodingOpenPGP    = "OpenPGP"
+	encodingOpenSSH    = "OpenSSH"
+	encodingPKCS12     = "PKCS#12"
+	encodingPKCS7      = "PKCS#7"
 )
 
+// pkcs12PasswordSuffixes are appended to the path of a PKCS#12 bundle to find
+// the sibling file holding its password, e.g. keystore.p12.pass.
+var pkcs12PasswordSuffixes = []string{".pass", ".password"}
+
+// certificate is a parsed certificate along with the alias it is stored under
+// in a Java KeyStore. The alias is empty for every other encoding.
+type certificate struct {
+	alias string
+	cert  *x509.Certificate
+}
+
 type Config struct {
 	Paths []string
+	// OCSPChecker checks the OCSP status of certificates whose issuer is found
+	// next to them. OCSP is not checked if nil.
+	OCSPChecker *revocation.Checker
+	// Filter applies to every path without an entry in PathFilters.
+	Filter Filter
+	// PathFilters override Filter for the cert paths they are keyed by.
+	PathFilters map[string]Filter
+	// PKCS12Passwords are tried in order to open PKCS#12 bundles that have no
+	// sibling password file, or whose sibling password does not open them.
+	PKCS12Passwords []string
+	// Policy certificates are audited against. Certificates are not audited
+	// if nil.
+	Policy *policy.Policy
+	// TrustStorePaths are walked like Paths, but only summarised per path
+	// instead of exporting one series per certificate.
+	TrustStorePaths []string
+	// TrustStoreExpiryWindow is how far ahead certificates of trust stores
+	// are counted as expiring.
+	TrustStoreExpiryWindow time.Duration
+	// Watch the cert paths with inotify and only walk them again once they
+	// changed. Without it every scrape walks them, parsing changed files only.
+	Watch bool
 }
 
 type Exporter struct {
-	cert   *prometheus.Desc
-	fs     afero.Fs
-	logger micrologger.Logger
+	cert                *prometheus.Desc
+	chainNotAfter       *prometheus.Desc
+	chainValid          *prometheus.Desc
+	crlNextUpdate       *prometheus.Desc
+	crlRevoked          *prometheus.Desc
+	crlThisUpdate       *prometheus.Desc
+	fs                  afero.Fs
+	info                *prometheus.Desc
+	keyPairMatch        *prometheus.Desc
+	kubeconfigNotAfter  *prometheus.Desc
+	kubeconfigNotBefore *prometheus.Desc
+	logger              micrologger.Logger
+	notBefore           *prometheus.Desc
+	ocspStatus          *prometheus.Desc
+	openPGPNotAfter     *prometheus.Desc
+	policyViolation     *prometheus.Desc
+	privateKeys         *prometheus.Desc
+	revoked             *prometheus.Desc
+	sshValidBefore      *prometheus.Desc
+	trustStoreCerts     *prometheus.Desc
+	trustStoreExpired   *prometheus.Desc
+	trustStoreExpiring  *prometheus.Desc
+	trustStoreNotAfter  *prometheus.Desc
+	usageMismatch       *prometheus.Desc
+
+	// cache holds the parsed files per cert path and file path. mutex
+	// serializes concurrent scrapes using it.
+	cache map[string]map[string]cachedFile
+	mutex sync.Mutex
+
+	// walked tells which cert paths did not change since they were last
+	// walked, as reported by watcher. It is nil when not watching.
+	// dependencies maps files outside of the walked folders to the cert
+	// paths depending on them.
+	dependencies map[string][]string
+	walked       map[string]bool
+	walkedMutex  sync.Mutex
+	watcher      *fsnotify.Watcher
 
-	paths []string
+	filter          Filter
+	ocsp            *revocation.Checker
+	pathFilters     map[string]Filter
+	paths           []string
+	pkcs12Passwords []string
+	policy          *policy.Policy
+
+	// trustStores tells which of paths are summarised as trust stores.
+	trustStoreExpiryWindow time.Duration
+	trustStores            map[string]bool
 }
 
 func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
@@ -30,6 +130,7 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 
 	// Check every path.
 	certsPathNotFoundErrorCount := 0
+	var collected []string
 	for _, p := range e.paths {
 		e.logger.Log("debug", fmt.Sprintf("checking cert path %s", p))
 		err := e.collectPath(ch, p)
@@ -38,9 +139,15 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 			e.logger.Log("debug", fmt.Sprintf("cert path not found %s", p))
 		} else if err != nil {
 			e.logger.Log("error", microerror.Mask(err))
+		} else if !e.trustStores[p] {
+			collected = append(collected, p)
 		}
 	}
 
+	// CRLs are often kept apart from the certificates they revoke, so they
+	// are matched once every cert path was collected.
+	e.exportRevoked(ch, collected)
+
 	// Check if we found at least one certificates directory
 	foundCertsPaths := len(e.paths) - certsPathNotFoundErrorCount
 	if foundCertsPaths == 0 {
@@ -59,63 +166,309 @@ func (e *Exporter) collectPath(ch chan<- prometheus.Metric, path string) error {
 		e.logger.Log("error", microerror.Mask(err))
 		return nil
 	}
-	err = afero.Walk(e.fs, path, func(fpath string, info os.FileInfo, err error) error {
-		if !info.IsDir() {
-			e.logger.Log("debug", fmt.Sprintf("checking cert %s", fpath))
-			file, err := afero.ReadFile(e.fs, fpath)
-			if err != nil {
-				e.logger.Log("error", microerror.Mask(err))
-				return err
-			}
 
-			if e.fileIsPrivateKey(file) {
-				e.logger.Log("info", fmt.Sprintf("not adding private key %s to the metrics", fpath))
-				return nil
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.needsWalk(path) {
+		err = e.walkPath(path)
+		if err != nil {
+			return err
+		}
+	} else {
+		e.logger.Log("debug", fmt.Sprintf("serving unchanged cert path %s from the cache", path))
+	}
+
+	if e.trustStores[path] {
+		e.exportTrustStore(ch, path)
+		return nil
+	}
+
+	privateKeys := 0
+	for _, fpath := range e.cachedPaths(path) {
+		parsed := e.cache[path][fpath].parsed
+		privateKeys += len(parsed.privateKeys)
+		e.exportFile(ch, fpath, parsed)
+	}
+	ch <- prometheus.MustNewConstMetric(e.privateKeys, prometheus.GaugeValue, float64(privateKeys), path)
+	e.exportKeyPairs(ch, path)
+	e.exportChains(ch, path)
+	e.exportOCSP(ch, path)
+
+	e.logger.Log("debug", fmt.Sprintf("found cert path %s", path))
+	return nil
+}
+
+// parseFile reads everything the exporter reports on from the given file
+// contents. The result is cached until the file changes.
+func (e *Exporter) parseFile(fpath string, file []byte) parsedFile {
+	if fileIsKubeconfig(file) {
+		certs, dependencies, err := e.parseKubeconfig(fpath, file)
+		if err == nil {
+			return parsedFile{
+				dependencies:    dependencies,
+				encoding:        encodingKubeconfig,
+				kubeconfigCerts: certs,
 			}
+		}
+
+		e.logger.Log("debug", fmt.Sprintf("%s could not be parsed as a kubeconfig: %s", fpath, microerror.Mask(err)))
+	}
+
+	if fileIsOpenPGPKey(file) {
+		return parsedFile{
+			encoding:    encodingOpenPGP,
+			openPGPKeys: e.parseOpenPGPKeys(fpath, file),
+		}
+	}
+
+	if fileIsSSHCertificate(file) {
+		return parsedFile{
+			encoding: encodingOpenSSH,
+			sshCerts: e.parseSSHCertificates(fpath, file),
+		}
+	}
+
+	dependencies := map[string]fileState{}
+	certs, encoding := e.parseCertificates(fpath, file, dependencies)
+
+	return parsedFile{
+		certs:        certs,
+		crls:         e.parseCRLs(fpath, file),
+		dependencies: dependencies,
+		encoding:     encoding,
+		privateKeys:  parsePrivateKeys(file),
+	}
+}
+
+func (e *Exporter) exportFile(ch chan<- prometheus.Metric, fpath string, parsed parsedFile) {
+	if len(parsed.privateKeys) > 0 && len(parsed.certs) == 0 {
+		e.logger.Log("info", fmt.Sprintf("not adding private key %s to the metrics", fpath))
+		return
+	}
 
-			rest := file
-			for {
-				block, remaining := pem.Decode(rest)
-				if block == nil {
-					break
-				}
-				rest = remaining
-
-				certs, err := x509.ParseCertificates(block.Bytes)
-				if err != nil {
-					e.logger.Log("warning", fmt.Sprintf("%s could not be parsed as a certificate: %s", fpath, microerror.Mask(err)))
-					continue
-				}
-
-				for _, cert := range certs {
-					timestamp := float64(cert.NotAfter.Unix())
-					serialNumber := fmt.Sprintf("%x", cert.SerialNumber)
-					ch <- prometheus.MustNewConstMetric(e.cert, prometheus.GaugeValue, timestamp, fpath, serialNumber)
-				}
+	for _, c := range parsed.certs {
+		serialNumber := fmt.Sprintf("%x", c.cert.SerialNumber)
+		ch <- prometheus.MustNewConstMetric(e.cert, prometheus.GaugeValue, float64(c.cert.NotAfter.Unix()), fpath, serialNumber, c.alias)
+		ch <- prometheus.MustNewConstMetric(e.notBefore, prometheus.GaugeValue, float64(c.cert.NotBefore.Unix()), fpath, serialNumber, c.alias)
+		ch <- prometheus.MustNewConstMetric(e.info, prometheus.GaugeValue, 1, append([]string{fpath, serialNumber, c.alias}, certinfo.Labels(c.cert)...)...)
+	}
+	e.exportPolicyViolations(ch, fpath, parsed.certs)
+	e.exportUsageMismatches(ch, fpath, parsed.certs)
+	e.exportKubeconfig(ch, fpath, parsed.kubeconfigCerts)
+	e.exportCRLs(ch, fpath, parsed.crls)
+	e.exportSSHCertificates(ch, fpath, parsed.sshCerts)
+	e.exportOpenPGPKeys(ch, fpath, parsed.openPGPKeys)
+	e.logger.Log("info", fmt.Sprintf("added %s (%s) to the metrics", fpath, parsed.encoding))
+}
+
+// filterFor returns the filter applying to the given cert path.
+func (e *Exporter) filterFor(path string) Filter {
+	filter, ok := e.pathFilters[path]
+	if !ok {
+		return e.filter
+	}
+
+	return filter
+}
+
+// parseCertificates returns the certificates held by the given file contents,
+// along with the encoding they were found in. PEM files may hold any number of
+// blocks, binary DER files (.der/.cer) hold one or more concatenated
+// certificates without any armor, PKCS#12 bundles (.p12/.pfx) hold a keystore
+// or trust store, PKCS#7 bundles (.p7b/.p7c) hold a chain, either as DER or as
+// PEM blocks, and Java KeyStores (JKS/JCEKS) hold aliased entries. The files
+// read along with the given one are recorded in dependencies.
+func (e *Exporter) parseCertificates(fpath string, file []byte, dependencies map[string]fileState) ([]certificate, string) {
+	if keystore.IsJKS(file) {
+		entries, err := keystore.DecodeJKS(file)
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("%s could not be read as a Java KeyStore: %s", fpath, microerror.Mask(err)))
+		}
+
+		var certs []certificate
+		for _, entry := range entries {
+			certs = append(certs, certificate{alias: entry.Alias, cert: entry.Certificate})
+		}
+
+		return certs, encodingJKS
+	}
+
+	if keystore.IsPKCS12(file) {
+		certs, err := keystore.DecodePKCS12(file, e.pkcs12PasswordsFor(fpath, dependencies))
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("%s could not be opened as a PKCS#12 bundle: %s", fpath, microerror.Mask(err)))
+			return nil, encodingPKCS12
+		}
+
+		return withoutAlias(certs), encodingPKCS12
+	}
+
+	if keystore.IsPKCS7(file) {
+		certs, err := keystore.DecodePKCS7(file)
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("%s could not be read as a PKCS#7 bundle: %s", fpath, microerror.Mask(err)))
+			return nil, encodingPKCS7
+		}
+
+		return withoutAlias(certs), encodingPKCS7
+	}
+
+	if fileIsDER(file) {
+		if fileIsDERPrivateKey(file) || fileIsDERCRL(file) {
+			return nil, encodingDER
+		}
+
+		certs, err := x509.ParseCertificates(file)
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("%s could not be parsed as a DER certificate: %s", fpath, microerror.Mask(err)))
+			return nil, encodingDER
+		}
+
+		return withoutAlias(certs), encodingDER
+	}
+
+	var certs []certificate
+	rest := file
+	for {
+		block, remaining := pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		rest = remaining
+
+		// Keys are commonly bundled with their certificate, e.g. by kubelet
+		// client certificate rotation, and have no expiry to report on.
+		if blockIsPrivateKey(block) || block.Type == revocation.BlockType {
+			continue
+		}
+
+		if keystore.IsPKCS7Block(block) {
+			parsed, err := keystore.DecodePKCS7(block.Bytes)
+			if err != nil {
+				e.logger.Log("warning", fmt.Sprintf("%s could not be read as a PKCS#7 bundle: %s", fpath, microerror.Mask(err)))
+				continue
 			}
-			e.logger.Log("info", fmt.Sprintf("added %s to the metrics", fpath))
 
+			certs = append(certs, withoutAlias(parsed)...)
+			continue
 		}
 
-		e.logger.Log("debug", fmt.Sprintf("found cert path %s", path))
-		return nil
-	})
-	if err != nil {
-		return err
+		parsed, err := x509.ParseCertificates(block.Bytes)
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("%s could not be parsed as a certificate: %s", fpath, microerror.Mask(err)))
+			continue
+		}
+
+		certs = append(certs, withoutAlias(parsed)...)
 	}
 
-	return nil
+	return certs, encodingPEM
 }
 
-// fileIsPrivateKey returns true if the given file contents are an RSA private key, false otherwise.
-// As keys don't have expiry date, we don't try to export expiry metrics for them.
-func (e *Exporter) fileIsPrivateKey(f []byte) bool {
-	return strings.Contains(string(f), "RSA PRIVATE KEY")
+func withoutAlias(certs []*x509.Certificate) []certificate {
+	var result []certificate
+	for _, cert := range certs {
+		result = append(result, certificate{cert: cert})
+	}
+
+	return result
+}
+
+// pkcs12PasswordsFor returns the passwords to try for the PKCS#12 bundle at the
+// given path: the contents of a sibling password file first, then the
+// configured passwords and finally the empty password. The sibling password
+// files are recorded in dependencies whether they exist or not, so creating
+// or changing one opens the bundle again.
+func (e *Exporter) pkcs12PasswordsFor(fpath string, dependencies map[string]fileState) []string {
+	var passwords []string
+	for _, suffix := range pkcs12PasswordSuffixes {
+		passwordPath := fpath + suffix
+		dependencies[passwordPath] = e.stateAt(passwordPath)
+
+		password, err := afero.ReadFile(e.fs, passwordPath)
+		if err != nil {
+			continue
+		}
+
+		passwords = append(passwords, strings.TrimRight(string(password), "\r\n"))
+	}
+
+	passwords = append(passwords, e.pkcs12Passwords...)
+
+	return append(passwords, "")
+}
+
+// fileIsDER returns true if the given file contents are made of binary ASN.1
+// SEQUENCEs only, which is how DER encoded certificates and chains of them are
+// stored. Text, like a PEM file whose first comment line starts with a 0, may
+// look like the start of a SEQUENCE but never spans the whole file as one.
+func fileIsDER(f []byte) bool {
+	rest := f
+	for len(rest) > 0 {
+		var v asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &v)
+		if err != nil {
+			return false
+		}
+
+		if v.Class != asn1.ClassUniversal || v.Tag != asn1.TagSequence || !v.IsCompound {
+			return false
+		}
+	}
+
+	return len(f) > 0
+}
+
+// blockIsPrivateKey returns true if the given PEM block holds a private key of
+// any kind: PKCS#1 RSA, SEC 1 EC, PKCS#8 plain or encrypted and OpenSSH keys
+// all use a block type ending in PRIVATE KEY. As keys don't have expiry date,
+// we don't try to export expiry metrics for them.
+func blockIsPrivateKey(block *pem.Block) bool {
+	return strings.HasSuffix(block.Type, "PRIVATE KEY")
+}
+
+// fileIsDERPrivateKey returns true if the given binary DER file contents are a
+// PKCS#8, PKCS#1 RSA or SEC 1 EC private key rather than a certificate.
+func fileIsDERPrivateKey(f []byte) bool {
+	return publicKeyOf(f) != nil
+}
+
+// parsePrivateKeys returns the public halves of the private keys held by the
+// given file contents, either as PEM blocks or as a single binary DER key. The
+// public half is nil for keys that cannot be read, like encrypted ones, so the
+// result still counts them.
+func parsePrivateKeys(f []byte) []crypto.PublicKey {
+	if fileIsDER(f) {
+		if fileIsDERPrivateKey(f) {
+			return []crypto.PublicKey{publicKeyOf(f)}
+		}
+
+		return nil
+	}
+
+	var keys []crypto.PublicKey
+	rest := f
+	for {
+		block, remaining := pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		rest = remaining
+
+		if blockIsPrivateKey(block) {
+			keys = append(keys, publicKeyOf(block.Bytes))
+		}
+	}
+
+	return keys
 }
 
 // newCertDesc describes the exported metric. Kept separate from New so tests can
 // assert against the real label set instead of a copy of it. The serialnumber
-// label is what keeps concatenated certificates from colliding into one series.
+// label is what keeps concatenated certificates from colliding into one series,
+// the alias label tells the entries of a Java KeyStore apart.
 func newCertDesc() *prometheus.Desc {
 	return prometheus.NewDesc(
 		prometheus.BuildFQName("cert_exporter", "", "not_after"),
@@ -123,6 +476,50 @@ func newCertDesc() *prometheus.Desc {
 		[]string{
 			"path",
 			"serialnumber",
+			"alias",
+		},
+		nil,
+	)
+}
+
+// newNotBeforeDesc describes the not before metric, which shares the label set
+// of the not after metric so both can be joined.
+func newNotBeforeDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "", "not_before"),
+		"Timestamp before which the cert is not yet valid.",
+		[]string{
+			"path",
+			"serialnumber",
+			"alias",
+		},
+		nil,
+	)
+}
+
+// newInfoDesc describes the certificate info metric, which is joinable to the
+// not after metric through its path, serialnumber and alias labels.
+func newInfoDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "", "certificate_info"),
+		"Information about the cert, always 1.",
+		append([]string{
+			"path",
+			"serialnumber",
+			"alias",
+		}, certinfo.LabelNames...),
+		nil,
+	)
+}
+
+// newPrivateKeysDesc describes the number of private keys skipped below each
+// cert path, so key material lying around in unexpected places shows up.
+func newPrivateKeysDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "", "private_keys_found"),
+		"Number of private keys found below the cert path.",
+		[]string{
+			"path",
 		},
 		nil,
 	)
@@ -130,12 +527,89 @@ func newCertDesc() *prometheus.Desc {
 
 func DefaultConfig() Config {
 	return Config{
-		Paths: []string{},
+		Paths:           []string{},
+		PathFilters:     map[string]Filter{},
+		PKCS12Passwords: []string{},
+		TrustStorePaths: []string{},
+		// Long enough to ship a trust store update ahead of a CA expiring.
+		TrustStoreExpiryWindow: 30 * 24 * time.Hour,
 	}
 }
 
 func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.cert
+	ch <- e.chainNotAfter
+	ch <- e.chainValid
+	ch <- e.crlNextUpdate
+	ch <- e.crlRevoked
+	ch <- e.crlThisUpdate
+	ch <- e.info
+	ch <- e.keyPairMatch
+	ch <- e.kubeconfigNotAfter
+	ch <- e.kubeconfigNotBefore
+	ch <- e.notBefore
+	ch <- e.ocspStatus
+	ch <- e.openPGPNotAfter
+	ch <- e.policyViolation
+	ch <- e.privateKeys
+	ch <- e.revoked
+	ch <- e.sshValidBefore
+	ch <- e.trustStoreCerts
+	ch <- e.trustStoreExpired
+	ch <- e.trustStoreExpiring
+	ch <- e.trustStoreNotAfter
+	ch <- e.usageMismatch
+}
+
+// newExporter wires up an exporter reading from the given file system. Kept
+// separate from New so tests use the real descriptors instead of copies.
+func newExporter(fs afero.Fs, logger micrologger.Logger, config Config) *Exporter {
+	// Trust stores are walked, cached and watched like any other cert path.
+	paths := append([]string{}, config.Paths...)
+	trustStores := map[string]bool{}
+	for _, path := range config.TrustStorePaths {
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+		trustStores[path] = true
+	}
+
+	return &Exporter{
+		cert:                newCertDesc(),
+		chainNotAfter:       newChainNotAfterDesc(),
+		chainValid:          newChainValidDesc(),
+		crlNextUpdate:       newCRLNextUpdateDesc(),
+		crlRevoked:          newCRLRevokedDesc(),
+		crlThisUpdate:       newCRLThisUpdateDesc(),
+		fs:                  fs,
+		info:                newInfoDesc(),
+		keyPairMatch:        newKeyPairMatchDesc(),
+		kubeconfigNotAfter:  newKubeconfigNotAfterDesc(),
+		kubeconfigNotBefore: newKubeconfigNotBeforeDesc(),
+		logger:              logger,
+		notBefore:           newNotBeforeDesc(),
+		ocspStatus:          newOCSPStatusDesc(),
+		openPGPNotAfter:     newOpenPGPNotAfterDesc(),
+		policyViolation:     newPolicyViolationDesc(),
+		privateKeys:         newPrivateKeysDesc(),
+		revoked:             newRevokedDesc(),
+		sshValidBefore:      newSSHValidBeforeDesc(),
+		trustStoreCerts:     newTrustStoreCertsDesc(),
+		trustStoreExpired:   newTrustStoreExpiredDesc(),
+		trustStoreExpiring:  newTrustStoreExpiringDesc(),
+		trustStoreNotAfter:  newTrustStoreNotAfterDesc(),
+		usageMismatch:       newUsageMismatchDesc(),
+
+		filter:          config.Filter,
+		ocsp:            config.OCSPChecker,
+		pathFilters:     config.PathFilters,
+		paths:           paths,
+		pkcs12Passwords: config.PKCS12Passwords,
+		policy:          config.Policy,
+
+		trustStoreExpiryWindow: config.TrustStoreExpiryWindow,
+		trustStores:            trustStores,
+	}
 }
 
 func New(config Config) (*Exporter, error) {
@@ -144,13 +618,29 @@ func New(config Config) (*Exporter, error) {
 		return nil, err
 	}
 
+	err = config.Filter.validate()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	for path, filter := range config.PathFilters {
+		err = filter.validate()
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "filter of %s: %s", path, err)
+		}
+	}
+
 	fs := afero.NewOsFs()
 	logger.Log("info", "creating new exporter")
 
-	return &Exporter{
-		cert:   newCertDesc(),
-		fs:     fs,
-		logger: logger,
-		paths:  config.Paths,
-	}, nil
+	e := newExporter(fs, logger, config)
+
+	if config.Watch {
+		err = e.watch()
+		if err != nil {
+			// Walking on every scrape still works, only slower.
+			logger.Log("warning", fmt.Sprintf("cert paths cannot be watched: %s", microerror.Mask(err)))
+		}
+	}
+
+	return e, nil
 }
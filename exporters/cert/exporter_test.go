@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
@@ -17,6 +18,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/afero"
+	"software.sslmate.com/src/go-pkcs12"
+
+	"github.com/giantswarm/cert-exporter/pkg/keystore/keystoretest"
 )
 
 const metricName = "cert_exporter_not_after"
@@ -29,17 +33,12 @@ func newTestExporter(t *testing.T, fs afero.Fs, paths []string) *Exporter {
 		t.Fatal(err)
 	}
 
-	return &Exporter{
-		// The production descriptor, so a change to the exported labels is
-		// caught here instead of silently passing against a copy.
-		cert:   newCertDesc(),
-		fs:     fs,
-		logger: logger,
-		paths:  paths,
-	}
+	// The production descriptors, so a change to the exported labels is
+	// caught here instead of silently passing against a copy.
+	return newExporter(fs, logger, Config{Paths: paths})
 }
 
-func generateSelfSignedCertPEM(t *testing.T, notAfter time.Time) []byte {
+func generateSelfSignedCert(t *testing.T, notAfter time.Time) (*x509.Certificate, *ecdsa.PrivateKey) {
 	t.Helper()
 
 	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
@@ -60,7 +59,20 @@ func generateSelfSignedCertPEM(t *testing.T, notAfter time.Time) []byte {
 		t.Fatal(err)
 	}
 
-	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+func generateSelfSignedCertPEM(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+
+	cert, _ := generateSelfSignedCert(t, notAfter)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
 }
 
 func TestCollectPath_SingleCert(t *testing.T) {
@@ -72,7 +84,7 @@ func TestCollectPath_SingleCert(t *testing.T) {
 
 	e := newTestExporter(t, fs, []string{"/certs"})
 
-	ch := make(chan prometheus.Metric, 10)
+	ch := make(chan prometheus.Metric, 100)
 	err := e.collectPath(ch, "/certs")
 	if err != nil {
 		t.Fatal(err)
@@ -81,7 +93,10 @@ func TestCollectPath_SingleCert(t *testing.T) {
 
 	var metrics []prometheus.Metric
 	for m := range ch {
-		metrics = append(metrics, m)
+		// Only count the not after metric, not the ones emitted alongside it.
+		if m.Desc() == e.cert {
+			metrics = append(metrics, m)
+		}
 	}
 
 	if len(metrics) != 1 {
@@ -101,7 +116,7 @@ func TestCollectPath_MultipleCertsInSameFile(t *testing.T) {
 
 	e := newTestExporter(t, fs, []string{"/certs"})
 
-	ch := make(chan prometheus.Metric, 10)
+	ch := make(chan prometheus.Metric, 100)
 	err := e.collectPath(ch, "/certs")
 	if err != nil {
 		t.Fatal(err)
@@ -110,7 +125,10 @@ func TestCollectPath_MultipleCertsInSameFile(t *testing.T) {
 
 	var metrics []prometheus.Metric
 	for m := range ch {
-		metrics = append(metrics, m)
+		// Only count the not after metric, not the ones emitted alongside it.
+		if m.Desc() == e.cert {
+			metrics = append(metrics, m)
+		}
 	}
 
 	if len(metrics) != 2 {
@@ -118,6 +136,358 @@ func TestCollectPath_MultipleCertsInSameFile(t *testing.T) {
 	}
 }
 
+// TestCollectPath_DERCert covers binary DER files (.der/.cer), which carry no
+// PEM armor and used to be skipped silently.
+func TestCollectPath_DERCert(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	block, _ := pem.Decode(generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour)))
+
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/ca.der", block.Bytes, 0644)
+	_ = afero.WriteFile(fs, "/certs/tls.crt", generateSelfSignedCertPEM(t, time.Now().Add(48*time.Hour)), 0644)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(e); err != nil {
+		t.Fatal(err)
+	}
+
+	code, body := serveMetrics(t, reg)
+	if code != http.StatusOK {
+		t.Fatalf("expected /metrics to return 200, got %d", code)
+	}
+
+	if got := len(samplesFor(body, "/certs/ca.der")); got != 1 {
+		t.Fatalf("expected the DER certificate to be served once, got %d samples", got)
+	}
+	if got := len(samplesFor(body, "/certs/tls.crt")); got != 1 {
+		t.Fatalf("expected the PEM certificate to be served once, got %d samples", got)
+	}
+}
+
+// TestCollectPath_PKCS12 covers PKCS#12 keystores, opened with the password of
+// a sibling file or, failing that, one of the configured passwords.
+func TestCollectPath_PKCS12(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	leaf, key := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+	ca, _ := generateSelfSignedCert(t, time.Now().Add(48*time.Hour))
+
+	withSibling, err := pkcs12.Modern.Encode(key, leaf, []*x509.Certificate{ca}, "sibling")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withConfigured, err := pkcs12.Modern.Encode(key, leaf, nil, "configured")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/keystore.p12", withSibling, 0644)
+	_ = afero.WriteFile(fs, "/certs/keystore.p12.pass", []byte("sibling\n"), 0644)
+	_ = afero.WriteFile(fs, "/certs/client.pfx", withConfigured, 0644)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+	e.pkcs12Passwords = []string{"configured"}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(e); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	if got := len(samplesFor(body, "/certs/keystore.p12")); got != 2 {
+		t.Fatalf("expected leaf and CA of the keystore to be served, got %d samples", got)
+	}
+	if got := len(samplesFor(body, "/certs/client.pfx")); got != 1 {
+		t.Fatalf("expected the keystore opened with the configured password to be served, got %d samples", got)
+	}
+}
+
+// TestCollectPath_PKCS7 covers PKCS#7 bundles shipping a chain, both as binary
+// DER and as PEM blocks next to plain certificates.
+func TestCollectPath_PKCS7(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	leaf, _ := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+	ca, _ := generateSelfSignedCert(t, time.Now().Add(48*time.Hour))
+	other, _ := generateSelfSignedCert(t, time.Now().Add(72*time.Hour))
+
+	bundle := keystoretest.EncodePKCS7(t, []*x509.Certificate{leaf, ca})
+
+	armored := pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: bundle})
+	armored = append(armored, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: other.Raw})...)
+
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/chain.p7b", bundle, 0644)
+	_ = afero.WriteFile(fs, "/certs/chain.pem", armored, 0644)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(e); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	if got := len(samplesFor(body, "/certs/chain.p7b")); got != 2 {
+		t.Fatalf("expected both certificates of the DER bundle to be served, got %d samples", got)
+	}
+	if got := len(samplesFor(body, "/certs/chain.pem")); got != 3 {
+		t.Fatalf("expected the PEM bundle and the certificate next to it to be served, got %d samples", got)
+	}
+}
+
+// TestScrape_JKSModifiedUTF8Aliases covers aliases written in the modified
+// UTF-8 of Java, which is not valid UTF-8 and must not fail the scrape of the
+// other files.
+func TestScrape_JKSModifiedUTF8Aliases(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	ca, _ := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+	other, _ := generateSelfSignedCert(t, time.Now().Add(48*time.Hour))
+
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/truststore.jks", keystoretest.EncodeTrustStoreJKS(t, map[string]*x509.Certificate{
+		"a\xc0\x80b":  ca,
+		"caf\xc3\xa9": other,
+	}), 0644)
+	_ = afero.WriteFile(fs, "/certs/tls.crt", generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour)), 0644)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(e); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	if got := len(samplesFor(body, "/certs/truststore.jks")); got != 2 {
+		t.Fatalf("expected one sample per alias, got %d in\n%s", got, body)
+	}
+	if !strings.Contains(body, `alias="café"`) {
+		t.Errorf("expected the alias to be decoded in\n%s", body)
+	}
+	if got := len(samplesFor(body, "/certs/tls.crt")); got != 1 {
+		t.Errorf("expected the other file to be served, got %d samples", got)
+	}
+}
+
+// TestScrape_JKSAliases makes sure every alias of a Java KeyStore is served
+// as its own series, even when two aliases hold the same certificate.
+func TestScrape_JKSAliases(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	ca, _ := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+	other, _ := generateSelfSignedCert(t, time.Now().Add(48*time.Hour))
+
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/truststore.jks", keystoretest.EncodeTrustStoreJKS(t, map[string]*x509.Certificate{
+		"root":      ca,
+		"root-copy": ca,
+		"other":     other,
+	}), 0644)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(e); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	samples := samplesFor(body, "/certs/truststore.jks")
+	if len(samples) != 3 {
+		t.Fatalf("expected one sample per alias, got %d", len(samples))
+	}
+	for _, alias := range []string{"root", "root-copy", "other"} {
+		var found bool
+		for _, sample := range samples {
+			if strings.Contains(sample, `alias="`+alias+`"`) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("expected a sample with alias %q", alias)
+		}
+	}
+}
+
+// TestScrape_NotBefore makes sure every certificate is also served as a not
+// before sample carrying the same labels as its not after sample.
+func TestScrape_NotBefore(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	cert, _ := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/tls.crt", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0644)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(e); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	labels := fmt.Sprintf(`{alias="",path="/certs/tls.crt",serialnumber="%x"}`, cert.SerialNumber)
+	expected := fmt.Sprintf("cert_exporter_not_before%s %v", labels, float64(cert.NotBefore.Unix()))
+	if !strings.Contains(body, expected) {
+		t.Fatalf("expected sample %q in\n%s", expected, body)
+	}
+	if !strings.Contains(body, metricName+labels) {
+		t.Fatalf("expected a not after sample with labels %s", labels)
+	}
+}
+
+// TestScrape_CertificateInfo makes sure the info sample of a certificate can
+// be joined to its not after sample through the serialnumber label.
+func TestScrape_CertificateInfo(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	cert, _ := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/tls.crt", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0644)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(e); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	var info []string
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "cert_exporter_certificate_info{") {
+			info = append(info, line)
+		}
+	}
+	if len(info) != 1 {
+		t.Fatalf("expected 1 info sample, got %d", len(info))
+	}
+
+	for _, label := range []string{
+		fmt.Sprintf(`serialnumber="%x"`, cert.SerialNumber),
+		`path="/certs/tls.crt"`,
+		`key_algorithm="ECDSA"`,
+		`key_size="256"`,
+		`is_ca="false"`,
+	} {
+		if !strings.Contains(info[0], label) {
+			t.Fatalf("expected info sample to carry %s, got %s", label, info[0])
+		}
+	}
+	if !strings.HasSuffix(info[0], " 1") {
+		t.Fatalf("expected info sample to be 1, got %s", info[0])
+	}
+}
+
+// TestCollectPath_Filter makes sure the filter of a cert path keeps the walker
+// away from excluded, too deep and too large files.
+func TestCollectPath_Filter(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	certPEM := generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour))
+
+	_ = fs.MkdirAll("/etc/kubernetes/pki/etcd/nested", 0755)
+	_ = fs.MkdirAll("/etc/kubernetes/logs", 0755)
+	_ = afero.WriteFile(fs, "/etc/kubernetes/pki/ca.crt", certPEM, 0644)
+	_ = afero.WriteFile(fs, "/etc/kubernetes/pki/etcd/ca.crt", certPEM, 0644)
+	_ = afero.WriteFile(fs, "/etc/kubernetes/pki/etcd/nested/ca.crt", certPEM, 0644)
+	_ = afero.WriteFile(fs, "/etc/kubernetes/pki/bundle.crt", append(make([]byte, 4096), certPEM...), 0644)
+	_ = afero.WriteFile(fs, "/etc/kubernetes/logs/audit.crt", certPEM, 0644)
+	_ = afero.WriteFile(fs, "/etc/kubernetes/manifest.yaml", certPEM, 0644)
+
+	e := newTestExporter(t, fs, []string{"/etc/kubernetes"})
+	e.pathFilters = map[string]Filter{
+		"/etc/kubernetes": {
+			Include:     []string{"*.crt"},
+			Exclude:     []string{"logs"},
+			MaxDepth:    3,
+			MaxFileSize: 4096,
+		},
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(e); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	expected := map[string]int{
+		"/etc/kubernetes/pki/ca.crt":             1,
+		"/etc/kubernetes/pki/etcd/ca.crt":        1,
+		"/etc/kubernetes/pki/etcd/nested/ca.crt": 0,
+		"/etc/kubernetes/pki/bundle.crt":         0,
+		"/etc/kubernetes/logs/audit.crt":         0,
+		"/etc/kubernetes/manifest.yaml":          0,
+	}
+	for path, count := range expected {
+		if got := len(samplesFor(body, path)); got != count {
+			t.Fatalf("expected %d samples for %s, got %d", count, path, got)
+		}
+	}
+}
+
+func TestFileIsDER(t *testing.T) {
+	certPEM := generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour))
+	block, _ := pem.Decode(certPEM)
+
+	testCases := []struct {
+		name     string
+		file     []byte
+		expected bool
+	}{
+		{name: "DER certificate", file: block.Bytes, expected: true},
+		{name: "PEM certificate", file: certPEM, expected: false},
+		{name: "plain text", file: []byte("just some log line\n"), expected: false},
+		{name: "empty file", file: []byte{}, expected: false},
+		{name: "DER chain", file: append(append([]byte{}, block.Bytes...), block.Bytes...), expected: true},
+		{name: "PEM with a comment starting with 0", file: append([]byte("0 certificates below are issued by the ingress CA\n"), certPEM...), expected: false},
+		{name: "DER certificate with trailing data", file: append(append([]byte{}, block.Bytes...), "\n"...), expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := fileIsDER(tc.file); got != tc.expected {
+				t.Fatalf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestCollectPath_PEMLookingLikeDER covers a PEM bundle whose first comment
+// line starts with a 0, which reads like the start of an ASN.1 SEQUENCE.
+func TestCollectPath_PEMLookingLikeDER(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	bundle := append([]byte("0 certificates below are issued by the ingress CA\n"), generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour))...)
+	bundle = append(bundle, generateSelfSignedCertPEM(t, time.Now().Add(48*time.Hour))...)
+
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/bundle.pem", bundle, 0644)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+
+	body := scrape(t, e)
+
+	if got := len(samplesFor(body, "/certs/bundle.pem")); got != 2 {
+		t.Fatalf("expected 2 samples, got %d in\n%s", got, body)
+	}
+}
+
 // TestGather_MultipleCertsInSameFile guards against the regression where two
 // certs concatenated in a single file produced metrics with identical label
 // sets, causing Gather() to fail and blanking out the whole scrape.
@@ -145,7 +515,9 @@ func TestGather_MultipleCertsInSameFile(t *testing.T) {
 
 	var series int
 	for _, mf := range mfs {
-		series += len(mf.GetMetric())
+		if mf.GetName() == metricName {
+			series += len(mf.GetMetric())
+		}
 	}
 	if series != 2 {
 		t.Fatalf("expected 2 distinct series for concatenated certs, got %d", series)
@@ -232,7 +604,9 @@ func TestGather_SameCertInTwoFiles(t *testing.T) {
 
 	var series int
 	for _, mf := range mfs {
-		series += len(mf.GetMetric())
+		if mf.GetName() == metricName {
+			series += len(mf.GetMetric())
+		}
 	}
 	if series != 2 {
 		t.Fatalf("expected 1 series per file, got %d", series)
@@ -274,14 +648,12 @@ func TestCollectPath_PrivateKeySkipped(t *testing.T) {
 	fs := afero.NewMemMapFs()
 
 	_ = fs.MkdirAll("/certs", 0755)
-	// Contains the substring "RSA PRIVATE KEY" which is what fileIsPrivateKey checks.
-	// Avoiding the full PEM header to not trigger gitleaks false positives.
-	_ = afero.WriteFile(fs, "/certs/tls.key", []byte("not a real cert, just contains RSA PRIVATE KEY marker"), 0644)
+	_ = afero.WriteFile(fs, "/certs/tls.key", generatePrivateKeyPEM(t, "RSA PRIVATE KEY"), 0644)
 	_ = afero.WriteFile(fs, "/certs/tls.crt", generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour)), 0644)
 
 	e := newTestExporter(t, fs, []string{"/certs"})
 
-	ch := make(chan prometheus.Metric, 10)
+	ch := make(chan prometheus.Metric, 100)
 	err := e.collectPath(ch, "/certs")
 	if err != nil {
 		t.Fatal(err)
@@ -290,7 +662,10 @@ func TestCollectPath_PrivateKeySkipped(t *testing.T) {
 
 	var metrics []prometheus.Metric
 	for m := range ch {
-		metrics = append(metrics, m)
+		// Only count the not after metric, not the ones emitted alongside it.
+		if m.Desc() == e.cert {
+			metrics = append(metrics, m)
+		}
 	}
 
 	// Only tls.crt should produce a metric, not the private key.
@@ -298,3 +673,61 @@ func TestCollectPath_PrivateKeySkipped(t *testing.T) {
 		t.Fatalf("expected 1 metric (private key skipped), got %d", len(metrics))
 	}
 }
+
+// generatePrivateKeyPEM returns a PEM block of the given private key type.
+// Only the block type matters to the exporter, so the block carries a freshly
+// generated PKCS#8 key whatever the type. Encoding it at runtime keeps key
+// headers out of the source, which gitleaks would flag.
+func generatePrivateKeyPEM(t *testing.T, blockType string) []byte {
+	t.Helper()
+
+	_, key := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+// TestScrape_PrivateKeysFound makes sure every kind of private key is skipped
+// and counted per cert path, while certificates bundled with a key are still
+// exported.
+func TestScrape_PrivateKeysFound(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	cert, key := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/rsa.key", generatePrivateKeyPEM(t, "RSA PRIVATE KEY"), 0600)
+	_ = afero.WriteFile(fs, "/certs/ec.key", generatePrivateKeyPEM(t, "EC PRIVATE KEY"), 0600)
+	_ = afero.WriteFile(fs, "/certs/pkcs8.key", generatePrivateKeyPEM(t, "PRIVATE KEY"), 0600)
+	_ = afero.WriteFile(fs, "/certs/encrypted.key", generatePrivateKeyPEM(t, "ENCRYPTED PRIVATE KEY"), 0600)
+	_ = afero.WriteFile(fs, "/certs/id_ed25519", generatePrivateKeyPEM(t, "OPENSSH PRIVATE KEY"), 0600)
+	_ = afero.WriteFile(fs, "/certs/tls.der", keyDER, 0600)
+	_ = afero.WriteFile(fs, "/certs/client-current.pem", append(generateSelfSignedCertPEM(t, cert.NotAfter), generatePrivateKeyPEM(t, "EC PRIVATE KEY")...), 0600)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+
+	body := scrape(t, e)
+
+	if !strings.Contains(body, `cert_exporter_private_keys_found{path="/certs"} 7`) {
+		t.Errorf("expected 7 private keys to be found, got\n%s", body)
+	}
+
+	samples := samplesFor(body, "/certs/client-current.pem")
+	if len(samples) != 1 || !strings.Contains(samples[0], fmt.Sprintf(`serialnumber="%x"`, cert.SerialNumber)) {
+		t.Errorf("expected the certificate bundled with its key to be exported, got %v", samples)
+	}
+
+	for _, fpath := range []string{"/certs/rsa.key", "/certs/ec.key", "/certs/pkcs8.key", "/certs/encrypted.key", "/certs/id_ed25519", "/certs/tls.der"} {
+		if got := len(samplesFor(body, fpath)); got != 0 {
+			t.Errorf("expected no samples for %s, got %d", fpath, got)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cert
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+// Filter restricts which files below a cert path are read, so broad roots
+// like /etc/kubernetes can be monitored without parsing logs and binaries.
+type Filter struct {
+	// Include globs select the files to read. A glob is matched against the
+	// file name or, if it contains a slash, against the path relative to the
+	// cert path. No globs include every file.
+	Include []string
+	// Exclude globs skip files and whole directories, matched like Include.
+	// They take precedence over Include.
+	Exclude []string
+	// MaxDepth is the number of directory levels walked, 1 reading only the
+	// files directly inside the cert path. 0 means unlimited.
+	MaxDepth int
+	// MaxFileSize is the size in bytes above which files are skipped. 0 means
+	// unlimited.
+	MaxFileSize int64
+}
+
+// ParsePathFilter parses a per path filter of the form
+//
+//	<path>:include=<glob>,<glob>;exclude=<glob>;max-depth=<n>;max-file-size=<bytes>
+//
+// Keys not given are taken from defaults.
+func ParsePathFilter(value string, defaults Filter) (string, Filter, error) {
+	path, options, found := strings.Cut(value, ":")
+	if !found || path == "" {
+		return "", Filter{}, microerror.Maskf(invalidConfigError, "path filter %#q must start with a path followed by a colon", value)
+	}
+
+	filter := defaults
+	for _, option := range strings.Split(options, ";") {
+		if option == "" {
+			continue
+		}
+
+		key, v, found := strings.Cut(option, "=")
+		if !found {
+			return "", Filter{}, microerror.Maskf(invalidConfigError, "path filter option %#q must be of the form key=value", option)
+		}
+
+		switch key {
+		case "include":
+			filter.Include = splitGlobs(v)
+		case "exclude":
+			filter.Exclude = splitGlobs(v)
+		case "max-depth":
+			depth, err := strconv.Atoi(v)
+			if err != nil {
+				return "", Filter{}, microerror.Maskf(invalidConfigError, "max-depth %#q must be a number", v)
+			}
+			filter.MaxDepth = depth
+		case "max-file-size":
+			size, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return "", Filter{}, microerror.Maskf(invalidConfigError, "max-file-size %#q must be a number of bytes", v)
+			}
+			filter.MaxFileSize = size
+		default:
+			return "", Filter{}, microerror.Maskf(invalidConfigError, "unknown path filter option %#q", key)
+		}
+	}
+
+	return path, filter, nil
+}
+
+func splitGlobs(v string) []string {
+	if v == "" {
+		return nil
+	}
+
+	return strings.Split(v, ",")
+}
+
+// validate makes sure all globs are well formed, so a typo fails at startup
+// instead of silently never matching.
+func (f Filter) validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		_, err := filepath.Match(pattern, "")
+		if err != nil {
+			return microerror.Maskf(invalidConfigError, "glob %#q: %s", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// includes returns true if the file at the given path relative to the cert
+// path has to be read.
+func (f Filter) includes(rel string) bool {
+	if matchesAny(f.Exclude, rel) {
+		return false
+	}
+
+	return len(f.Include) == 0 || matchesAny(f.Include, rel)
+}
+
+// skipsDir returns true if the directory at the given path relative to the
+// cert path must not be walked.
+func (f Filter) skipsDir(rel string) bool {
+	if matchesAny(f.Exclude, rel) {
+		return true
+	}
+
+	depth := len(strings.Split(filepath.ToSlash(rel), "/"))
+
+	return f.MaxDepth > 0 && depth >= f.MaxDepth
+}
+
+func matchesAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		name := filepath.Base(rel)
+		if strings.Contains(pattern, "/") {
+			name = filepath.ToSlash(rel)
+		}
+
+		ok, _ := filepath.Match(pattern, name)
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
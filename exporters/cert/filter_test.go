@@ -0,0 +1,114 @@
+package cert
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePathFilter(t *testing.T) {
+	defaults := Filter{Exclude: []string{"*.log"}, MaxFileSize: 1024}
+
+	testCases := []struct {
+		name         string
+		value        string
+		expectedPath string
+		expected     Filter
+		expectedErr  bool
+	}{
+		{
+			name:         "all options",
+			value:        "/etc/kubernetes:include=*.crt,pki/*.pem;exclude=*.key;max-depth=2;max-file-size=4096",
+			expectedPath: "/etc/kubernetes",
+			expected: Filter{
+				Include:     []string{"*.crt", "pki/*.pem"},
+				Exclude:     []string{"*.key"},
+				MaxDepth:    2,
+				MaxFileSize: 4096,
+			},
+		},
+		{
+			name:         "defaults kept",
+			value:        "/etc/ssl/certs:max-depth=1",
+			expectedPath: "/etc/ssl/certs",
+			expected:     Filter{Exclude: []string{"*.log"}, MaxDepth: 1, MaxFileSize: 1024},
+		},
+		{
+			name:        "missing path",
+			value:       "include=*.crt",
+			expectedErr: true,
+		},
+		{
+			name:        "unknown option",
+			value:       "/etc/ssl/certs:depth=1",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid number",
+			value:       "/etc/ssl/certs:max-file-size=1M",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, filter, err := ParsePathFilter(tc.value, defaults)
+			if tc.expectedErr {
+				if !IsInvalidConfig(err) {
+					t.Fatalf("expected invalid config error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if path != tc.expectedPath {
+				t.Fatalf("expected path %q, got %q", tc.expectedPath, path)
+			}
+			if !reflect.DeepEqual(filter, tc.expected) {
+				t.Fatalf("expected filter %#v, got %#v", tc.expected, filter)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	filter := Filter{
+		Include:  []string{"*.crt", "pki/*.pem"},
+		Exclude:  []string{"old-*", "logs"},
+		MaxDepth: 2,
+	}
+
+	includes := map[string]bool{
+		"tls.crt":        true,
+		"ssl/tls.crt":    true,
+		"pki/ca.pem":     true,
+		"ca.pem":         false,
+		"old-ca.crt":     false,
+		"kube-apiserver": false,
+	}
+	for rel, expected := range includes {
+		if got := filter.includes(rel); got != expected {
+			t.Fatalf("expected includes(%q) to be %t, got %t", rel, expected, got)
+		}
+	}
+
+	skipsDir := map[string]bool{
+		"ssl":       false,
+		"logs":      true,
+		"ssl/etcd":  true,
+		"old-certs": true,
+	}
+	for rel, expected := range skipsDir {
+		if got := filter.skipsDir(rel); got != expected {
+			t.Fatalf("expected skipsDir(%q) to be %t, got %t", rel, expected, got)
+		}
+	}
+}
+
+func TestFilter_InvalidGlob(t *testing.T) {
+	err := Filter{Include: []string{"[*.crt"}}.validate()
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected invalid config error, got %v", err)
+	}
+}
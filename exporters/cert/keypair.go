@@ -0,0 +1,121 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// keyPairSuffixes map the suffix of a certificate file to the suffixes of the
+// key files it is paired with: tls.crt with tls.key, and cfssl's ca.pem with
+// ca-key.pem.
+var keyPairSuffixes = map[string][]string{
+	".crt": {".key"},
+	".pem": {"-key.pem", ".key"},
+}
+
+// publicKeyOf returns the public half of the given DER encoded PKCS#8, PKCS#1
+// RSA or SEC 1 EC private key, or nil if it is none of them.
+func publicKeyOf(der []byte) crypto.PublicKey {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer.Public()
+		}
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key.Public()
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key.Public()
+	}
+
+	return nil
+}
+
+// keyPairFor returns the path of the key the certificate at the given path is
+// paired with below the cert path, along with its public half. Files holding
+// both, like kubelet's kubelet-client-current.pem, are paired with themselves.
+func (e *Exporter) keyPairFor(path, fpath string) (string, crypto.PublicKey, bool) {
+	files := e.cache[path]
+
+	if keys := files[fpath].parsed.privateKeys; len(keys) > 0 {
+		return fpath, keys[0], true
+	}
+
+	for suffix, keySuffixes := range keyPairSuffixes {
+		if !strings.HasSuffix(fpath, suffix) {
+			continue
+		}
+
+		for _, keySuffix := range keySuffixes {
+			kpath := strings.TrimSuffix(fpath, suffix) + keySuffix
+			if kpath == fpath {
+				continue
+			}
+
+			if keys := files[kpath].parsed.privateKeys; len(keys) > 0 {
+				return kpath, keys[0], true
+			}
+		}
+	}
+
+	return "", nil, false
+}
+
+// exportKeyPairs reports whether the leaf certificate of every file paired
+// with a key below the given cert path matches that key. Keys that cannot be
+// read, like encrypted ones, are not reported on.
+func (e *Exporter) exportKeyPairs(ch chan<- prometheus.Metric, path string) {
+	for _, fpath := range e.cachedPaths(path) {
+		certs := e.cache[path][fpath].parsed.certs
+		if len(certs) == 0 {
+			continue
+		}
+
+		kpath, public, ok := e.keyPairFor(path, fpath)
+		if !ok {
+			continue
+		}
+		if public == nil {
+			e.logger.Log("debug", fmt.Sprintf("not matching %s against unreadable key %s", fpath, kpath))
+			continue
+		}
+
+		match := 0.0
+		if keyPairMatches(certs[0].cert, public) {
+			match = 1
+		} else {
+			e.logger.Log("warning", fmt.Sprintf("certificate %s does not match private key %s", fpath, kpath))
+		}
+
+		ch <- prometheus.MustNewConstMetric(e.keyPairMatch, prometheus.GaugeValue, match, fpath, kpath)
+	}
+}
+
+// keyPairMatches returns true if the given public key is the one the
+// certificate was issued for.
+func keyPairMatches(cert *x509.Certificate, public crypto.PublicKey) bool {
+	key, ok := public.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return false
+	}
+
+	return key.Equal(cert.PublicKey)
+}
+
+// newKeyPairMatchDesc describes whether a certificate file matches the private
+// key next to it, to catch a rotated certificate paired with a stale key.
+func newKeyPairMatchDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "", "keypair_match"),
+		"Whether the cert matches the private key next to it, 1 if it does and 0 otherwise.",
+		[]string{
+			"path",
+			"key_path",
+		},
+		nil,
+	)
+}
@@ -0,0 +1,60 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func encodePrivateKeyPEM(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func TestScrape_KeyPairMatch(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	tlsCert, tlsKey := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+	_, staleKey := generateSelfSignedCert(t, time.Now().Add(48*time.Hour))
+	kubeletCert, kubeletKey := generateSelfSignedCert(t, time.Now().Add(72*time.Hour))
+
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/tls.crt", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsCert.Raw}), 0644)
+	_ = afero.WriteFile(fs, "/certs/tls.key", encodePrivateKeyPEM(t, tlsKey), 0600)
+	_ = afero.WriteFile(fs, "/certs/ca.pem", generateSelfSignedCertPEM(t, time.Now().Add(96*time.Hour)), 0644)
+	_ = afero.WriteFile(fs, "/certs/ca-key.pem", encodePrivateKeyPEM(t, staleKey), 0600)
+	_ = afero.WriteFile(fs, "/certs/kubelet-client-current.pem", append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: kubeletCert.Raw}), encodePrivateKeyPEM(t, kubeletKey)...), 0600)
+	_ = afero.WriteFile(fs, "/certs/front-proxy-ca.crt", generateSelfSignedCertPEM(t, time.Now().Add(120*time.Hour)), 0644)
+	_ = afero.WriteFile(fs, "/certs/encrypted.crt", generateSelfSignedCertPEM(t, time.Now().Add(144*time.Hour)), 0644)
+	// The key cannot be read without its passphrase, so it is not matched.
+	_ = afero.WriteFile(fs, "/certs/encrypted.key", pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte("ciphertext")}), 0600)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+
+	var samples []string
+	for _, line := range strings.Split(scrape(t, e), "\n") {
+		if strings.HasPrefix(line, "cert_exporter_keypair_match{") {
+			samples = append(samples, line)
+		}
+	}
+
+	expected := []string{
+		`cert_exporter_keypair_match{key_path="/certs/ca-key.pem",path="/certs/ca.pem"} 0`,
+		`cert_exporter_keypair_match{key_path="/certs/kubelet-client-current.pem",path="/certs/kubelet-client-current.pem"} 1`,
+		`cert_exporter_keypair_match{key_path="/certs/tls.key",path="/certs/tls.crt"} 1`,
+	}
+	if strings.Join(samples, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected samples\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(samples, "\n"))
+	}
+}
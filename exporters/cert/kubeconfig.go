@@ -0,0 +1,206 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/afero"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Kubeconfig fields holding certificates, reported in the field label.
+const (
+	fieldCertificateAuthority     = "certificate-authority"
+	fieldCertificateAuthorityData = "certificate-authority-data"
+	fieldClientCertificate        = "client-certificate"
+	fieldClientCertificateData    = "client-certificate-data"
+)
+
+// kubeconfigCert is a certificate embedded in or referenced by a kubeconfig.
+// CA certificates belong to a cluster, client certificates to a user.
+type kubeconfigCert struct {
+	cert    *x509.Certificate
+	cluster string
+	field   string
+	user    string
+}
+
+// fileIsKubeconfig returns true if the given file contents look like a
+// kubeconfig, such as admin.conf or kubelet.conf.
+func fileIsKubeconfig(f []byte) bool {
+	return bytes.Contains(f, []byte("kind: Config")) || (bytes.Contains(f, []byte("clusters:")) && bytes.Contains(f, []byte("users:")))
+}
+
+// parseKubeconfig returns the certificates of every cluster and user of the
+// given kubeconfig, along with the state of the files it references. Relative
+// references are resolved against the folder of the kubeconfig.
+func (e *Exporter) parseKubeconfig(fpath string, file []byte) ([]kubeconfigCert, map[string]fileState, error) {
+	config, err := clientcmd.Load(file)
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+
+	var certs []kubeconfigCert
+	dependencies := map[string]fileState{}
+
+	var clusters []string
+	for name := range config.Clusters {
+		clusters = append(clusters, name)
+	}
+	sort.Strings(clusters)
+
+	for _, name := range clusters {
+		cluster := config.Clusters[name]
+
+		field, parsed := e.kubeconfigField(fpath, cluster.CertificateAuthorityData, fieldCertificateAuthorityData, cluster.CertificateAuthority, fieldCertificateAuthority, dependencies)
+		for _, cert := range parsed {
+			certs = append(certs, kubeconfigCert{cert: cert, cluster: name, field: field})
+		}
+	}
+
+	var users []string
+	for name := range config.AuthInfos {
+		users = append(users, name)
+	}
+	sort.Strings(users)
+
+	for _, name := range users {
+		user := config.AuthInfos[name]
+
+		field, parsed := e.kubeconfigField(fpath, user.ClientCertificateData, fieldClientCertificateData, user.ClientCertificate, fieldClientCertificate, dependencies)
+		for _, cert := range parsed {
+			certs = append(certs, kubeconfigCert{cert: cert, user: name, field: field})
+		}
+	}
+
+	return certs, dependencies, nil
+}
+
+// kubeconfigField returns the certificates of a kubeconfig field pair, which
+// either embeds them as data or references a file holding them, along with
+// the name of the field they were found in.
+func (e *Exporter) kubeconfigField(fpath string, data []byte, dataField string, ref string, refField string, dependencies map[string]fileState) (string, []*x509.Certificate) {
+	if len(data) > 0 {
+		certs, err := decodeCertificateBlocks(data)
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("%s of %s could not be parsed as a certificate: %s", dataField, fpath, microerror.Mask(err)))
+		}
+
+		return dataField, certs
+	}
+
+	if ref == "" {
+		return refField, nil
+	}
+
+	if !filepath.IsAbs(ref) {
+		ref = filepath.Join(filepath.Dir(fpath), ref)
+	}
+
+	info, err := e.fs.Stat(ref)
+	if err != nil {
+		e.logger.Log("warning", fmt.Sprintf("%s referenced by %s of %s could not be read: %s", ref, refField, fpath, microerror.Mask(err)))
+		return refField, nil
+	}
+	dependencies[ref] = stateOf(info)
+
+	file, err := afero.ReadFile(e.fs, ref)
+	if err != nil {
+		e.logger.Log("warning", fmt.Sprintf("%s referenced by %s of %s could not be read: %s", ref, refField, fpath, microerror.Mask(err)))
+		return refField, nil
+	}
+
+	certs, err := decodeCertificateBlocks(file)
+	if err != nil {
+		e.logger.Log("warning", fmt.Sprintf("%s referenced by %s of %s could not be parsed as a certificate: %s", ref, refField, fpath, microerror.Mask(err)))
+	}
+
+	return refField, certs
+}
+
+// decodeCertificateBlocks returns the certificates of all CERTIFICATE blocks
+// in the given PEM data, skipping the private keys combined files hold.
+func decodeCertificateBlocks(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := data
+	for {
+		block, remaining := pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		rest = remaining
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		parsed, err := x509.ParseCertificates(block.Bytes)
+		if err != nil {
+			return certs, microerror.Mask(err)
+		}
+
+		certs = append(certs, parsed...)
+	}
+
+	return certs, nil
+}
+
+// dependenciesUnchanged returns true if none of the given files changed since
+// the file depending on them was parsed.
+func (e *Exporter) dependenciesUnchanged(dependencies map[string]fileState) bool {
+	for dependency, state := range dependencies {
+		if e.stateAt(dependency) != state {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e *Exporter) exportKubeconfig(ch chan<- prometheus.Metric, fpath string, certs []kubeconfigCert) {
+	for _, c := range certs {
+		serialNumber := fmt.Sprintf("%x", c.cert.SerialNumber)
+		ch <- prometheus.MustNewConstMetric(e.kubeconfigNotAfter, prometheus.GaugeValue, float64(c.cert.NotAfter.Unix()), fpath, c.cluster, c.user, c.field, serialNumber)
+		ch <- prometheus.MustNewConstMetric(e.kubeconfigNotBefore, prometheus.GaugeValue, float64(c.cert.NotBefore.Unix()), fpath, c.cluster, c.user, c.field, serialNumber)
+	}
+}
+
+// newKubeconfigNotAfterDesc describes the not after metric of certificates
+// found in kubeconfigs, which are told apart by cluster, user and field.
+func newKubeconfigNotAfterDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "kubeconfig", "not_after"),
+		"Timestamp after which the cert of a kubeconfig is invalid.",
+		[]string{
+			"path",
+			"cluster",
+			"user",
+			"field",
+			"serialnumber",
+		},
+		nil,
+	)
+}
+
+// newKubeconfigNotBeforeDesc describes the not before metric of certificates
+// found in kubeconfigs, sharing the labels of the not after metric.
+func newKubeconfigNotBeforeDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "kubeconfig", "not_before"),
+		"Timestamp before which the cert of a kubeconfig is not yet valid.",
+		[]string{
+			"path",
+			"cluster",
+			"user",
+			"field",
+			"serialnumber",
+		},
+		nil,
+	)
+}
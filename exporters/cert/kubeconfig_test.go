@@ -0,0 +1,151 @@
+package cert
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func kubeconfigSamplesFor(body, path string) []string {
+	var samples []string
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "cert_exporter_kubeconfig_not_after{") && strings.Contains(line, `path="`+path+`"`) {
+			samples = append(samples, line)
+		}
+	}
+
+	return samples
+}
+
+func TestScrape_KubeconfigEmbedded(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	ca, _ := generateSelfSignedCert(t, time.Now().Add(10*365*24*time.Hour))
+	client, _ := generateSelfSignedCert(t, time.Now().Add(365*24*time.Hour))
+	caPEM := generateSelfSignedCertPEM(t, ca.NotAfter)
+	clientPEM := generateSelfSignedCertPEM(t, client.NotAfter)
+
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: kubernetes
+  cluster:
+    server: https://127.0.0.1:6443
+    certificate-authority-data: %s
+users:
+- name: kubernetes-admin
+  user:
+    client-certificate-data: %s
+contexts:
+- name: kubernetes-admin@kubernetes
+  context:
+    cluster: kubernetes
+    user: kubernetes-admin
+current-context: kubernetes-admin@kubernetes
+`, base64.StdEncoding.EncodeToString(caPEM), base64.StdEncoding.EncodeToString(clientPEM))
+
+	_ = fs.MkdirAll("/etc/kubernetes", 0755)
+	_ = afero.WriteFile(fs, "/etc/kubernetes/admin.conf", []byte(kubeconfig), 0600)
+
+	e := newTestExporter(t, fs, []string{"/etc/kubernetes"})
+
+	samples := kubeconfigSamplesFor(scrape(t, e), "/etc/kubernetes/admin.conf")
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 samples, got %v", samples)
+	}
+
+	expected := []string{
+		fmt.Sprintf(`cert_exporter_kubeconfig_not_after{cluster="",field="client-certificate-data",path="/etc/kubernetes/admin.conf",serialnumber="%x",user="kubernetes-admin"}`, client.SerialNumber),
+		fmt.Sprintf(`cert_exporter_kubeconfig_not_after{cluster="kubernetes",field="certificate-authority-data",path="/etc/kubernetes/admin.conf",serialnumber="%x",user=""}`, ca.SerialNumber),
+	}
+	for i, sample := range samples {
+		if !strings.HasPrefix(sample, expected[i]+" ") {
+			t.Errorf("expected sample %s, got %s", expected[i], sample)
+		}
+	}
+}
+
+// TestScrape_KubeconfigReferenced covers kubelet.conf, which references a
+// rotated client certificate outside of the walked folder, combined with its
+// key. Rotating it must invalidate the cached kubeconfig.
+func TestScrape_KubeconfigReferenced(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: default-cluster
+  cluster:
+    server: https://127.0.0.1:6443
+    certificate-authority: pki/ca.crt
+users:
+- name: default-auth
+  user:
+    client-certificate: /var/lib/kubelet/pki/kubelet-client-current.pem
+    client-key: /var/lib/kubelet/pki/kubelet-client-current.pem
+`
+	keyPEM := generatePrivateKeyPEM(t, "EC PRIVATE KEY")
+
+	ca, _ := generateSelfSignedCert(t, time.Now().Add(48*time.Hour))
+	client, _ := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+
+	_ = fs.MkdirAll("/etc/kubernetes/pki", 0755)
+	_ = fs.MkdirAll("/var/lib/kubelet/pki", 0755)
+	_ = afero.WriteFile(fs, "/etc/kubernetes/kubelet.conf", []byte(kubeconfig), 0600)
+	_ = afero.WriteFile(fs, "/etc/kubernetes/pki/ca.crt", generateSelfSignedCertPEM(t, ca.NotAfter), 0644)
+	_ = afero.WriteFile(fs, "/var/lib/kubelet/pki/kubelet-client-current.pem", append(generateSelfSignedCertPEM(t, client.NotAfter), keyPEM...), 0600)
+
+	e := newTestExporter(t, fs, []string{"/etc/kubernetes"})
+
+	body := scrape(t, e)
+	if got := len(kubeconfigSamplesFor(body, "/etc/kubernetes/kubelet.conf")); got != 2 {
+		t.Fatalf("expected 2 samples, got %d", got)
+	}
+	if !hasKubeconfigSample(body, "certificate-authority", fmt.Sprintf("%x", ca.SerialNumber)) {
+		t.Error("expected the relative CA reference to be resolved")
+	}
+	if !hasKubeconfigSample(body, "client-certificate", fmt.Sprintf("%x", client.SerialNumber)) {
+		t.Error("expected the client certificate reference to be resolved")
+	}
+
+	rotated, _ := generateSelfSignedCert(t, time.Now().Add(72*time.Hour))
+	_ = afero.WriteFile(fs, "/var/lib/kubelet/pki/kubelet-client-current.pem", append(generateSelfSignedCertPEM(t, rotated.NotAfter), keyPEM...), 0600)
+
+	if !hasKubeconfigSample(scrape(t, e), "client-certificate", fmt.Sprintf("%x", rotated.SerialNumber)) {
+		t.Fatal("expected the rotated client certificate to be served")
+	}
+}
+
+func hasKubeconfigSample(body, field, serialNumber string) bool {
+	for _, sample := range kubeconfigSamplesFor(body, "/etc/kubernetes/kubelet.conf") {
+		if strings.Contains(sample, `field="`+field+`"`) && strings.Contains(sample, `serialnumber="`+serialNumber+`"`) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestFileIsKubeconfig(t *testing.T) {
+	testCases := []struct {
+		name     string
+		file     string
+		expected bool
+	}{
+		{name: "kind", file: "apiVersion: v1\nkind: Config\n", expected: true},
+		{name: "clusters and users", file: "clusters: []\nusers: []\n", expected: true},
+		{name: "PEM", file: "-----BEGIN CERTIFICATE-----\n", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := fileIsKubeconfig([]byte(tc.file)); got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package cert
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/cert-exporter/pkg/chain"
+)
+
+// exportOCSP reports the OCSP status of the certificates below the given cert
+// path whose issuer is found below it, as last checked by the OCSP checker.
+// Certificates are only reported on once their responder answered.
+func (e *Exporter) exportOCSP(ch chan<- prometheus.Metric, path string) {
+	if e.ocsp == nil {
+		return
+	}
+
+	candidates := e.cachedCerts(path)
+	for _, fpath := range e.cachedPaths(path) {
+		for _, c := range e.cache[path][fpath].parsed.certs {
+			issuer := chain.Issuer(c.cert, candidates)
+			if issuer == nil {
+				continue
+			}
+
+			status, ok := e.ocsp.Status(c.cert, issuer)
+			if !ok {
+				continue
+			}
+
+			serialNumber := fmt.Sprintf("%x", c.cert.SerialNumber)
+			ch <- prometheus.MustNewConstMetric(e.ocspStatus, prometheus.GaugeValue, 1, fpath, serialNumber, c.alias, status)
+		}
+	}
+}
+
+// newOCSPStatusDesc describes the OCSP status of a certificate, given by the
+// status label as good, revoked or unknown.
+func newOCSPStatusDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "", "ocsp_status"),
+		"OCSP status of the cert as reported by its responder, always 1.",
+		[]string{
+			"path",
+			"serialnumber",
+			"alias",
+			"status",
+		},
+		nil,
+	)
+}
@@ -0,0 +1,96 @@
+package cert
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/afero"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/giantswarm/cert-exporter/pkg/certtest"
+	"github.com/giantswarm/cert-exporter/pkg/revocation"
+)
+
+func TestScrape_OCSPStatus(t *testing.T) {
+	ca, caKey := certtest.Generate(t, "ca", true, time.Now().Add(365*24*time.Hour), nil, nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req, err := ocsp.ParseRequest(body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		resp, err := ocsp.CreateResponse(ca, ca, ocsp.Response{
+			Status:       ocsp.Revoked,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now().Add(-1 * time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now().Add(-1 * time.Hour),
+		}, caKey)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		_, _ = w.Write(resp)
+	}))
+	defer srv.Close()
+
+	_, leafKey := certtest.Generate(t, "unused", false, time.Now().Add(time.Hour), nil, nil)
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		OCSPServer:   []string{srv.URL},
+	}, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := afero.NewMemMapFs()
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/ca.crt", encodeCertsPEM(ca), 0644)
+	_ = afero.WriteFile(fs, "/certs/tls.crt", encodeCertsPEM(leaf), 0644)
+
+	logger, err := micrologger.New(micrologger.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker, err := revocation.NewChecker(revocation.CheckerConfig{Interval: time.Hour, Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go checker.Run(ctx)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+	e.ocsp = checker
+
+	// The first scrape tracks the certificate, a later one reports its status.
+	expected := fmt.Sprintf(`cert_exporter_ocsp_status{alias="",path="/certs/tls.crt",serialnumber="%x",status="revoked"} 1`, leaf.SerialNumber)
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(scrape(t, e), expected) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected sample %q", expected)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,122 @@
+package cert
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// openPGPKeyMarker starts every ASCII-armored OpenPGP public key.
+const openPGPKeyMarker = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+
+// openPGPKey is the primary key or a subkey of an OpenPGP public key.
+type openPGPKey struct {
+	fingerprint string
+	// notAfter is when the key expires, zero if it never does.
+	notAfter time.Time
+	primary  bool
+	// uid is the primary user ID of the key the subkey belongs to.
+	uid string
+}
+
+// fileIsOpenPGPKey returns true if the given file contents hold an
+// ASCII-armored OpenPGP public key, like the keys package repositories are
+// signed with.
+func fileIsOpenPGPKey(f []byte) bool {
+	return bytes.Contains(f, []byte(openPGPKeyMarker))
+}
+
+// parseOpenPGPKeys returns the primary keys and subkeys of every armored
+// public key block in the given file. Revoked subkeys are left out as they
+// are no longer used.
+func (e *Exporter) parseOpenPGPKeys(fpath string, file []byte) []openPGPKey {
+	var keys []openPGPKey
+	blocks := strings.Split(string(file), openPGPKeyMarker)
+	for _, block := range blocks[1:] {
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(openPGPKeyMarker + block))
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("%s could not be parsed as an OpenPGP public key: %s", fpath, microerror.Mask(err)))
+			continue
+		}
+
+		for _, entity := range entities {
+			var uid string
+			sig, identity := entity.PrimarySelfSignature()
+			if identity != nil {
+				// Legacy user IDs may be Latin-1, which label values
+				// must not be.
+				uid = strings.ToValidUTF8(identity.Name, "\uFFFD")
+			}
+
+			keys = append(keys, openPGPKey{
+				fingerprint: fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint),
+				notAfter:    openPGPKeyExpiry(entity.PrimaryKey, sig),
+				primary:     true,
+				uid:         uid,
+			})
+
+			for _, subkey := range entity.Subkeys {
+				if len(subkey.Revocations) > 0 {
+					continue
+				}
+
+				keys = append(keys, openPGPKey{
+					fingerprint: fmt.Sprintf("%X", subkey.PublicKey.Fingerprint),
+					notAfter:    openPGPKeyExpiry(subkey.PublicKey, subkey.Sig),
+					uid:         uid,
+				})
+			}
+		}
+	}
+
+	return keys
+}
+
+// openPGPKeyExpiry returns when the given key expires according to its self
+// signature, zero if it never does.
+func openPGPKeyExpiry(key *packet.PublicKey, sig *packet.Signature) time.Time {
+	if sig == nil || sig.KeyLifetimeSecs == nil || *sig.KeyLifetimeSecs == 0 {
+		return time.Time{}
+	}
+
+	return key.CreationTime.Add(time.Duration(*sig.KeyLifetimeSecs) * time.Second)
+}
+
+func (e *Exporter) exportOpenPGPKeys(ch chan<- prometheus.Metric, fpath string, keys []openPGPKey) {
+	for _, key := range keys {
+		notAfter := math.Inf(1)
+		if !key.notAfter.IsZero() {
+			notAfter = float64(key.notAfter.Unix())
+		}
+
+		keyType := "subkey"
+		if key.primary {
+			keyType = "primary"
+		}
+
+		ch <- prometheus.MustNewConstMetric(e.openPGPNotAfter, prometheus.GaugeValue, notAfter, fpath, key.fingerprint, key.uid, keyType)
+	}
+}
+
+// newOpenPGPNotAfterDesc describes the expiry of OpenPGP primary keys and
+// subkeys, which are told apart by their fingerprint.
+func newOpenPGPNotAfterDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "openpgp_key", "not_after"),
+		"Timestamp after which the OpenPGP key is expired.",
+		[]string{
+			"path",
+			"fingerprint",
+			"uid",
+			"type",
+		},
+		nil,
+	)
+}
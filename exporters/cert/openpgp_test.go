@@ -0,0 +1,100 @@
+package cert
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/spf13/afero"
+)
+
+func generateOpenPGPKey(t *testing.T, name string, lifetime, subkeyLifetime uint32) (*openpgp.Entity, []byte) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", &packet.Config{KeyLifetimeSecs: lifetime})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.AddSigningSubkey(&packet.Config{KeyLifetimeSecs: subkeyLifetime}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return entity, buf.Bytes()
+}
+
+func TestScrape_OpenPGPKeys(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	signing, signingKey := generateOpenPGPKey(t, "charts", 365*24*3600, 30*24*3600)
+	forever, foreverKey := generateOpenPGPKey(t, "packages", 0, 0)
+
+	_ = fs.MkdirAll("/etc/apt/keyrings", 0755)
+	_ = afero.WriteFile(fs, "/etc/apt/keyrings/charts.asc", signingKey, 0644)
+	_ = afero.WriteFile(fs, "/etc/apt/keyrings/packages.asc", foreverKey, 0644)
+
+	e := newTestExporter(t, fs, []string{"/etc/apt/keyrings"})
+
+	body := scrape(t, e)
+
+	uid := "charts <charts@example.com>"
+	primaryNotAfter := signing.PrimaryKey.CreationTime.Unix() + 365*24*3600
+	subkeyNotAfter := signing.Subkeys[1].PublicKey.CreationTime.Unix() + 30*24*3600
+	expected := []string{
+		fmt.Sprintf(`cert_exporter_openpgp_key_not_after{fingerprint="%X",path="/etc/apt/keyrings/charts.asc",type="primary",uid="%s"} %v`, signing.PrimaryKey.Fingerprint, uid, float64(primaryNotAfter)),
+		fmt.Sprintf(`cert_exporter_openpgp_key_not_after{fingerprint="%X",path="/etc/apt/keyrings/charts.asc",type="subkey",uid="%s"} %v`, signing.Subkeys[1].PublicKey.Fingerprint, uid, float64(subkeyNotAfter)),
+		fmt.Sprintf(`cert_exporter_openpgp_key_not_after{fingerprint="%X",path="/etc/apt/keyrings/packages.asc",type="primary",uid="packages <packages@example.com>"} +Inf`, forever.PrimaryKey.Fingerprint),
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in\n%s", sample, body)
+		}
+	}
+
+	// The encryption subkey created along with the key plus the signing one.
+	if got := strings.Count(body, `cert_exporter_openpgp_key_not_after{fingerprint=`); got != 6 {
+		t.Errorf("expected 6 keys, got %d", got)
+	}
+}
+
+// TestScrape_OpenPGPLatin1UserID makes sure a legacy user ID which is not
+// valid UTF-8 does not fail the scrape of the other files.
+func TestScrape_OpenPGPLatin1UserID(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	legacy, legacyKey := generateOpenPGPKey(t, "J\xfcrgen", 0, 0)
+	_, otherKey := generateOpenPGPKey(t, "packages", 0, 0)
+
+	_ = fs.MkdirAll("/etc/apt/keyrings", 0755)
+	_ = afero.WriteFile(fs, "/etc/apt/keyrings/legacy.asc", legacyKey, 0644)
+	_ = afero.WriteFile(fs, "/etc/apt/keyrings/packages.asc", otherKey, 0644)
+
+	e := newTestExporter(t, fs, []string{"/etc/apt/keyrings"})
+
+	body := scrape(t, e)
+
+	expected := []string{
+		fmt.Sprintf(`cert_exporter_openpgp_key_not_after{fingerprint="%X",path="/etc/apt/keyrings/legacy.asc",type="primary",uid="J�rgen <J�rgen@example.com>"} +Inf`, legacy.PrimaryKey.Fingerprint),
+		`path="/etc/apt/keyrings/packages.asc",type="primary",uid="packages <packages@example.com>"} +Inf`,
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in\n%s", sample, body)
+		}
+	}
+}
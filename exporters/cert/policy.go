@@ -0,0 +1,38 @@
+package cert
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// exportPolicyViolations reports every rule of the configured policy violated
+// by the certificates of a file. Compliant certificates are not reported.
+func (e *Exporter) exportPolicyViolations(ch chan<- prometheus.Metric, fpath string, certs []certificate) {
+	if e.policy == nil {
+		return
+	}
+
+	for _, c := range certs {
+		serialNumber := fmt.Sprintf("%x", c.cert.SerialNumber)
+		for _, rule := range e.policy.Violations(c.cert) {
+			ch <- prometheus.MustNewConstMetric(e.policyViolation, prometheus.GaugeValue, 1, fpath, serialNumber, c.alias, rule)
+		}
+	}
+}
+
+// newPolicyViolationDesc describes a rule of the policy violated by a
+// certificate, given by the rule label.
+func newPolicyViolationDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "", "policy_violation"),
+		"Rule of the policy violated by the cert, always 1.",
+		[]string{
+			"path",
+			"serialnumber",
+			"alias",
+			"rule",
+		},
+		nil,
+	)
+}
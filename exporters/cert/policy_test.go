@@ -0,0 +1,41 @@
+package cert
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/giantswarm/cert-exporter/pkg/policy"
+)
+
+func TestScrape_PolicyViolation(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	longLived, _ := generateSelfSignedCert(t, time.Now().Add(2*365*24*time.Hour))
+
+	_ = fs.MkdirAll("/certs", 0755)
+	_ = afero.WriteFile(fs, "/certs/long-lived.crt", encodeCertsPEM(longLived), 0644)
+	_ = afero.WriteFile(fs, "/certs/short-lived.crt", generateSelfSignedCertPEM(t, time.Now().Add(90*24*time.Hour)), 0644)
+
+	e := newTestExporter(t, fs, []string{"/certs"})
+
+	if body := scrape(t, e); strings.Contains(body, "cert_exporter_policy_violation{") {
+		t.Fatalf("expected no policy violations without a policy in\n%s", body)
+	}
+
+	p := policy.Default()
+	e.policy = &p
+
+	body := scrape(t, e)
+
+	expected := fmt.Sprintf(`cert_exporter_policy_violation{alias="",path="/certs/long-lived.crt",rule="validity-period",serialnumber="%x"} 1`, longLived.SerialNumber)
+	if !strings.Contains(body, expected) {
+		t.Errorf("expected sample %q in\n%s", expected, body)
+	}
+	if strings.Contains(body, `cert_exporter_policy_violation{alias="",path="/certs/short-lived.crt"`) {
+		t.Errorf("expected the compliant certificate not to be reported in\n%s", body)
+	}
+}
@@ -0,0 +1,89 @@
+package cert
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshCertificateMarker is contained in the key type of every OpenSSH
+// certificate, like ssh-ed25519-cert-v01@openssh.com.
+var sshCertificateMarker = []byte("-cert-v01@openssh.com")
+
+// fileIsSSHCertificate returns true if the given file contents look like an
+// OpenSSH certificate, such as /etc/ssh/ssh_host_ed25519_key-cert.pub.
+func fileIsSSHCertificate(f []byte) bool {
+	return bytes.Contains(f, sshCertificateMarker)
+}
+
+// parseSSHCertificates returns the OpenSSH certificates of the given file,
+// which holds one per line in authorized_keys format.
+func (e *Exporter) parseSSHCertificates(fpath string, file []byte) []*ssh.Certificate {
+	var certs []*ssh.Certificate
+	rest := file
+	for len(bytes.TrimSpace(rest)) > 0 {
+		key, _, _, remaining, err := ssh.ParseAuthorizedKey(rest)
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("%s could not be parsed as an OpenSSH certificate: %s", fpath, microerror.Mask(err)))
+			break
+		}
+		rest = remaining
+
+		cert, ok := key.(*ssh.Certificate)
+		if !ok {
+			continue
+		}
+
+		certs = append(certs, cert)
+	}
+
+	return certs
+}
+
+func (e *Exporter) exportSSHCertificates(ch chan<- prometheus.Metric, fpath string, certs []*ssh.Certificate) {
+	for _, cert := range certs {
+		certType := "user"
+		if cert.CertType == ssh.HostCert {
+			certType = "host"
+		}
+
+		// Key IDs are arbitrary bytes, which label values must not be.
+		keyID := strings.ToValidUTF8(cert.KeyId, "\uFFFD")
+
+		ch <- prometheus.MustNewConstMetric(e.sshValidBefore, prometheus.GaugeValue, sshTime(cert.ValidBefore), fpath, keyID, strconv.FormatUint(cert.Serial, 10), strconv.Itoa(len(cert.ValidPrincipals)), certType)
+	}
+}
+
+// sshTime converts an OpenSSH certificate timestamp to seconds, where
+// ssh.CertTimeInfinity means the certificate never expires.
+func sshTime(t uint64) float64 {
+	if t == ssh.CertTimeInfinity {
+		return math.Inf(1)
+	}
+
+	return float64(t)
+}
+
+// newSSHValidBeforeDesc describes the expiry of OpenSSH host and user
+// certificates, which are told apart by their key ID and serial, as CAs often
+// sign several certificates with the same key ID.
+func newSSHValidBeforeDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "ssh_cert", "valid_before"),
+		"Timestamp after which the OpenSSH cert is invalid.",
+		[]string{
+			"path",
+			"key_id",
+			"serial",
+			"principals",
+			"type",
+		},
+		nil,
+	)
+}
@@ -0,0 +1,140 @@
+package cert
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"golang.org/x/crypto/ssh"
+)
+
+func generateSSHCertificate(t *testing.T, certType uint32, keyID string, principals []string, validBefore uint64) []byte {
+	t.Helper()
+
+	return generateSSHCertificateWithSerial(t, certType, keyID, 0, principals, validBefore)
+}
+
+func generateSSHCertificateWithSerial(t *testing.T, certType uint32, keyID string, serial uint64, principals []string, validBefore uint64) []byte {
+	t.Helper()
+
+	_, caKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hostKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ssh.NewPublicKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := &ssh.Certificate{
+		Key:             pub,
+		CertType:        certType,
+		KeyId:           keyID,
+		Serial:          serial,
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(time.Now().Add(-1 * time.Hour).Unix()),
+		ValidBefore:     validBefore,
+	}
+	if err := cert.SignCert(rand.Reader, signer); err != nil {
+		t.Fatal(err)
+	}
+
+	return ssh.MarshalAuthorizedKey(cert)
+}
+
+func TestScrape_SSHCertificates(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	hostValidBefore := uint64(time.Now().Add(24 * time.Hour).Unix())
+	userValidBefore := uint64(time.Now().Add(8 * time.Hour).Unix())
+
+	_ = fs.MkdirAll("/etc/ssh", 0755)
+	_ = afero.WriteFile(fs, "/etc/ssh/ssh_host_ed25519_key-cert.pub", generateSSHCertificate(t, ssh.HostCert, "node-1", []string{"node-1", "node-1.internal"}, hostValidBefore), 0644)
+	_ = afero.WriteFile(fs, "/etc/ssh/bastion-cert.pub", generateSSHCertificate(t, ssh.UserCert, "ops@bastion", []string{"ops"}, userValidBefore), 0644)
+	_ = afero.WriteFile(fs, "/etc/ssh/forever-cert.pub", generateSSHCertificate(t, ssh.UserCert, "forever", nil, ssh.CertTimeInfinity), 0644)
+
+	e := newTestExporter(t, fs, []string{"/etc/ssh"})
+
+	body := scrape(t, e)
+
+	expected := []string{
+		fmt.Sprintf(`cert_exporter_ssh_cert_valid_before{key_id="node-1",path="/etc/ssh/ssh_host_ed25519_key-cert.pub",principals="2",serial="0",type="host"} %v`, float64(hostValidBefore)),
+		fmt.Sprintf(`cert_exporter_ssh_cert_valid_before{key_id="ops@bastion",path="/etc/ssh/bastion-cert.pub",principals="1",serial="0",type="user"} %v`, float64(userValidBefore)),
+		`cert_exporter_ssh_cert_valid_before{key_id="forever",path="/etc/ssh/forever-cert.pub",principals="0",serial="0",type="user"} +Inf`,
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in\n%s", sample, body)
+		}
+	}
+}
+
+// TestScrape_SSHCertificateInvalidKeyID makes sure a key ID which is not valid
+// UTF-8 does not fail the scrape of the other files.
+func TestScrape_SSHCertificateInvalidKeyID(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	validBefore := uint64(time.Now().Add(24 * time.Hour).Unix())
+
+	_ = fs.MkdirAll("/etc/ssh", 0755)
+	_ = afero.WriteFile(fs, "/etc/ssh/bad-cert.pub", generateSSHCertificate(t, ssh.UserCert, "bad\xff", nil, validBefore), 0644)
+	_ = afero.WriteFile(fs, "/etc/ssh/good-cert.pub", generateSSHCertificate(t, ssh.UserCert, "good", nil, validBefore), 0644)
+
+	e := newTestExporter(t, fs, []string{"/etc/ssh"})
+
+	body := scrape(t, e)
+
+	expected := []string{
+		fmt.Sprintf(`cert_exporter_ssh_cert_valid_before{key_id="bad�",path="/etc/ssh/bad-cert.pub",principals="0",serial="0",type="user"} %v`, float64(validBefore)),
+		fmt.Sprintf(`cert_exporter_ssh_cert_valid_before{key_id="good",path="/etc/ssh/good-cert.pub",principals="0",serial="0",type="user"} %v`, float64(validBefore)),
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in\n%s", sample, body)
+		}
+	}
+}
+
+// TestScrape_SSHCertificatesSameKeyID covers a file holding several
+// certificates signed with the same key ID, told apart by their serial.
+func TestScrape_SSHCertificatesSameKeyID(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	oldValidBefore := uint64(time.Now().Add(1 * time.Hour).Unix())
+	newValidBefore := uint64(time.Now().Add(24 * time.Hour).Unix())
+
+	file := append(
+		generateSSHCertificateWithSerial(t, ssh.UserCert, "deploy", 1, nil, oldValidBefore),
+		generateSSHCertificateWithSerial(t, ssh.UserCert, "deploy", 2, nil, newValidBefore)...,
+	)
+
+	_ = fs.MkdirAll("/etc/ssh", 0755)
+	_ = afero.WriteFile(fs, "/etc/ssh/deploy-cert.pub", file, 0644)
+
+	e := newTestExporter(t, fs, []string{"/etc/ssh"})
+
+	body := scrape(t, e)
+
+	expected := []string{
+		fmt.Sprintf(`cert_exporter_ssh_cert_valid_before{key_id="deploy",path="/etc/ssh/deploy-cert.pub",principals="0",serial="1",type="user"} %v`, float64(oldValidBefore)),
+		fmt.Sprintf(`cert_exporter_ssh_cert_valid_before{key_id="deploy",path="/etc/ssh/deploy-cert.pub",principals="0",serial="2",type="user"} %v`, float64(newValidBefore)),
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in\n%s", sample, body)
+		}
+	}
+}
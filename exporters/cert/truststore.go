@@ -0,0 +1,93 @@
+package cert
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/cert-exporter/pkg/certinfo"
+)
+
+// exportTrustStore summarises the certificates found below the given trust
+// store path instead of exporting one series per certificate. Stores like
+// /etc/ssl/certs reach the same CA through its file, hashed symlinks and the
+// concatenated bundle, so certificates are counted once per fingerprint.
+func (e *Exporter) exportTrustStore(ch chan<- prometheus.Metric, path string) {
+	now := time.Now()
+
+	seen := map[string]bool{}
+	var earliest time.Time
+	var expired, expiring int
+	for _, fpath := range e.cachedPaths(path) {
+		for _, c := range e.cache[path][fpath].parsed.certs {
+			fingerprint := certinfo.Fingerprint(c.cert)
+			if seen[fingerprint] {
+				continue
+			}
+			seen[fingerprint] = true
+
+			if earliest.IsZero() || c.cert.NotAfter.Before(earliest) {
+				earliest = c.cert.NotAfter
+			}
+			if c.cert.NotAfter.Before(now) {
+				expired++
+			} else if c.cert.NotAfter.Before(now.Add(e.trustStoreExpiryWindow)) {
+				expiring++
+			}
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(e.trustStoreCerts, prometheus.GaugeValue, float64(len(seen)), path)
+	ch <- prometheus.MustNewConstMetric(e.trustStoreExpired, prometheus.GaugeValue, float64(expired), path)
+	ch <- prometheus.MustNewConstMetric(e.trustStoreExpiring, prometheus.GaugeValue, float64(expiring), path)
+	if !earliest.IsZero() {
+		ch <- prometheus.MustNewConstMetric(e.trustStoreNotAfter, prometheus.GaugeValue, float64(earliest.Unix()), path)
+	}
+
+	e.logger.Log("debug", fmt.Sprintf("summarised %d certs of trust store %s", len(seen), path))
+}
+
+func newTrustStoreCertsDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "trust_store", "certificates"),
+		"Number of distinct certs in the trust store.",
+		[]string{
+			"path",
+		},
+		nil,
+	)
+}
+
+func newTrustStoreExpiredDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "trust_store", "expired_certificates"),
+		"Number of distinct certs in the trust store that are expired.",
+		[]string{
+			"path",
+		},
+		nil,
+	)
+}
+
+func newTrustStoreExpiringDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "trust_store", "expiring_certificates"),
+		"Number of distinct certs in the trust store that expire within the configured window.",
+		[]string{
+			"path",
+		},
+		nil,
+	)
+}
+
+func newTrustStoreNotAfterDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "trust_store", "earliest_not_after"),
+		"Timestamp after which the first cert of the trust store is invalid.",
+		[]string{
+			"path",
+		},
+		nil,
+	)
+}
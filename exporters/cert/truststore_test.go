@@ -0,0 +1,67 @@
+package cert
+
+import (
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/afero"
+)
+
+// TestScrape_TrustStore covers a trust store laid out like /etc/ssl/certs: a
+// CA reached through its file, a hashed symlink and the concatenated bundle is
+// counted once, and only summaries are exported instead of per cert series.
+func TestScrape_TrustStore(t *testing.T) {
+	dir := t.TempDir()
+
+	expired, _ := generateSelfSignedCert(t, time.Now().Add(-24*time.Hour))
+	expiring, _ := generateSelfSignedCert(t, time.Now().Add(10*24*time.Hour))
+	valid, _ := generateSelfSignedCert(t, time.Now().Add(365*24*time.Hour))
+
+	var bundle []byte
+	for name, cert := range map[string][]byte{"expired.pem": expired.Raw, "expiring.pem": expiring.Raw, "valid.pem": valid.Raw} {
+		encoded := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})
+		bundle = append(bundle, encoded...)
+		if err := os.WriteFile(filepath.Join(dir, name), encoded, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ca-certificates.crt"), bundle, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("valid.pem", filepath.Join(dir, "3513523f.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	logger, err := micrologger.New(micrologger.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newExporter(afero.NewOsFs(), logger, Config{
+		TrustStorePaths:        []string{dir},
+		TrustStoreExpiryWindow: 30 * 24 * time.Hour,
+	})
+
+	body := scrape(t, e)
+
+	expected := []string{
+		fmt.Sprintf(`cert_exporter_trust_store_certificates{path=%q} 3`, dir),
+		fmt.Sprintf(`cert_exporter_trust_store_expired_certificates{path=%q} 1`, dir),
+		fmt.Sprintf(`cert_exporter_trust_store_expiring_certificates{path=%q} 1`, dir),
+		fmt.Sprintf(`cert_exporter_trust_store_earliest_not_after{path=%q} %v`, dir, float64(expired.NotAfter.Unix())),
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in\n%s", sample, body)
+		}
+	}
+
+	if strings.Contains(body, metricName+"{") {
+		t.Errorf("expected no per cert series for a trust store in\n%s", body)
+	}
+}
@@ -0,0 +1,42 @@
+package cert
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/cert-exporter/pkg/usage"
+)
+
+// exportUsageMismatches reports every usage the certificates of a file lack
+// for the role implied by its name, like serverAuth for apiserver.crt. CA
+// certificates are checked as CAs wherever they are found, leaves in files
+// whose name implies no role are not checked.
+func (e *Exporter) exportUsageMismatches(ch chan<- prometheus.Metric, fpath string, certs []certificate) {
+	locationRole := usage.RoleOf(fpath)
+
+	for _, c := range certs {
+		role := usage.RoleFor(c.cert, locationRole)
+		serialNumber := fmt.Sprintf("%x", c.cert.SerialNumber)
+		for _, missing := range usage.Missing(c.cert, role) {
+			ch <- prometheus.MustNewConstMetric(e.usageMismatch, prometheus.GaugeValue, 1, fpath, serialNumber, c.alias, role, missing)
+		}
+	}
+}
+
+// newUsageMismatchDesc describes a usage a certificate lacks for its role,
+// given by the usage label.
+func newUsageMismatchDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "", "usage_mismatch"),
+		"Usage the cert lacks for the role implied by its location, always 1.",
+		[]string{
+			"path",
+			"serialnumber",
+			"alias",
+			"role",
+			"usage",
+		},
+		nil,
+	)
+}
@@ -0,0 +1,79 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func generateCertWithUsage(t *testing.T, serial int64, isCA bool, keyUsage x509.KeyUsage, extKeyUsage []x509.ExtKeyUsage) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		NotBefore:             time.Now().Add(-1 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		KeyUsage:              keyUsage,
+		ExtKeyUsage:           extKeyUsage,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+func TestScrape_UsageMismatch(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	ca := generateCertWithUsage(t, 1, true, x509.KeyUsageCRLSign, nil)
+	server := generateCertWithUsage(t, 2, false, x509.KeyUsageDigitalSignature, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth})
+	client := generateCertWithUsage(t, 3, false, x509.KeyUsageDigitalSignature, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth})
+	selfSigned := generateCertWithUsage(t, 4, false, x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth})
+
+	_ = fs.MkdirAll("/pki", 0755)
+	_ = afero.WriteFile(fs, "/pki/ca.crt", encodeCertsPEM(ca), 0644)
+	_ = afero.WriteFile(fs, "/pki/apiserver.crt", encodeCertsPEM(server), 0644)
+	_ = afero.WriteFile(fs, "/pki/apiserver-kubelet-client.crt", encodeCertsPEM(client), 0644)
+	_ = fs.MkdirAll("/pki/webhook", 0755)
+	_ = afero.WriteFile(fs, "/pki/webhook/ca.crt", encodeCertsPEM(selfSigned), 0644)
+
+	e := newTestExporter(t, fs, []string{"/pki"})
+
+	body := scrape(t, e)
+
+	expected := []string{
+		`cert_exporter_usage_mismatch{alias="",path="/pki/ca.crt",role="ca",serialnumber="1",usage="keyCertSign"} 1`,
+		`cert_exporter_usage_mismatch{alias="",path="/pki/apiserver.crt",role="server",serialnumber="2",usage="serverAuth"} 1`,
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in\n%s", sample, body)
+		}
+	}
+
+	if count := strings.Count(body, "cert_exporter_usage_mismatch{"); count != len(expected) {
+		t.Errorf("expected only %d usage mismatches, the client certificate complying and the leaf in ca.crt not checked, got %d in\n%s", len(expected), count, body)
+	}
+}
@@ -0,0 +1,134 @@
+package cert
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/giantswarm/microerror"
+)
+
+// watch starts watching the cert paths with inotify, so scrapes serve paths
+// without changes from the cache instead of walking them. Folders are added
+// to the watcher while they are walked.
+func (e *Exporter) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	e.dependencies = map[string][]string{}
+	e.walked = map[string]bool{}
+	e.watcher = watcher
+
+	go e.handleEvents()
+
+	return nil
+}
+
+func (e *Exporter) handleEvents() {
+	for {
+		select {
+		case event, ok := <-e.watcher.Events:
+			if !ok {
+				return
+			}
+
+			e.logger.Log("debug", fmt.Sprintf("%s changed (%s)", event.Name, event.Op))
+			e.invalidate(event.Name)
+
+		case err, ok := <-e.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			// Events might have been lost, e.g. when the queue overflowed, so
+			// no cached path can be trusted anymore.
+			e.logger.Log("warning", fmt.Sprintf("watching cert paths failed: %s", microerror.Mask(err)))
+			e.invalidate("")
+		}
+	}
+}
+
+// invalidate marks the cert paths containing the given file as changed, or
+// all of them for an empty name.
+func (e *Exporter) invalidate(name string) {
+	e.walkedMutex.Lock()
+	defer e.walkedMutex.Unlock()
+
+	for path := range e.walked {
+		if name == "" || name == path || strings.HasPrefix(name, strings.TrimSuffix(path, "/")+"/") {
+			e.walked[path] = false
+		}
+	}
+	for _, path := range e.dependencies[name] {
+		e.walked[path] = false
+	}
+}
+
+func (e *Exporter) setWalked(path string, walked bool) {
+	e.walkedMutex.Lock()
+	defer e.walkedMutex.Unlock()
+
+	e.walked[path] = walked
+}
+
+// watchDir adds a folder below the given cert path to the watcher. A folder
+// that cannot be watched keeps the cert path from being served from the cache.
+func (e *Exporter) watchDir(path, dir string) {
+	if e.watcher == nil {
+		return
+	}
+
+	err := e.watcher.Add(dir)
+	if err != nil {
+		e.logger.Log("warning", fmt.Sprintf("%s could not be watched: %s", dir, microerror.Mask(err)))
+		e.setWalked(path, false)
+	}
+}
+
+// watchDependency watches the folder of a file that a file below the given
+// cert path depends on, so changing it invalidates the cert path even if it
+// lives outside of it, like the client certificate of kubelet.conf.
+func (e *Exporter) watchDependency(path, dependency string) {
+	if e.watcher == nil {
+		return
+	}
+
+	e.walkedMutex.Lock()
+	if !slices.Contains(e.dependencies[dependency], path) {
+		e.dependencies[dependency] = append(e.dependencies[dependency], path)
+	}
+	e.walkedMutex.Unlock()
+
+	e.watchDir(path, filepath.Dir(dependency))
+}
+
+// watchSymlinkTarget watches the target of a symlink below the given cert
+// path, so changing it invalidates the cert path even if it lives outside of
+// it, like the CA certificates /etc/ssl/certs links to.
+func (e *Exporter) watchSymlinkTarget(path, symlink string) {
+	if e.watcher == nil {
+		return
+	}
+
+	target, err := filepath.EvalSymlinks(symlink)
+	if err != nil {
+		e.logger.Log("warning", fmt.Sprintf("%s could not be resolved: %s", symlink, microerror.Mask(err)))
+		e.setWalked(path, false)
+		return
+	}
+
+	e.watchDependency(path, target)
+}
+
+// Close stops watching the cert paths.
+func (e *Exporter) Close() error {
+	if e.watcher == nil {
+		return nil
+	}
+
+	return e.watcher.Close()
+}
@@ -12,9 +12,14 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+
+	"github.com/giantswarm/cert-exporter/pkg/scope"
 )
 
 var certManagerCertificateGroupVersionResource = schema.GroupVersionResource{
@@ -43,21 +48,69 @@ const (
 )
 
 type Config struct {
-	Namespaces []string
+	// ExcludedNamespaces are never read, whatever the other namespace fields
+	// select.
+	ExcludedNamespaces []string
+	// FieldSelector restricts the Certificates read. Only the metadata.name
+	// and metadata.namespace fields are supported for custom resources.
+	FieldSelector string
+	// LabelSelector restricts the Certificates read, like team=platform.
+	LabelSelector string
+	// NamespaceLabelSelector restricts the Certificates read to the
+	// namespaces matching it, which are watched so new namespaces are picked
+	// up.
+	NamespaceLabelSelector string
+	Namespaces             []string
 }
 
 type Exporter struct {
 	certNotAfter  *prometheus.Desc
+	certNotBefore *prometheus.Desc
 	ctx           context.Context
 	logger        micrologger.Logger
 	dynamicClient dynamic.Interface
 
-	namespaces []string
+	fieldSelector string
+	labelSelector string
+	namespaces    *scope.Namespaces
+}
+
+// newCertNotAfterDesc describes the not after metric. Kept separate from New so
+// tests can assert against the real label set.
+func newCertNotAfterDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "certificate_cr", "not_after"),
+		"Timestamp after which the cert is invalid.",
+		[]string{
+			"name",
+			"namespace",
+			"issuer_ref",
+			"managed_issuer",
+		},
+		nil,
+	)
+}
+
+// newCertNotBeforeDesc describes the not before metric, which shares the label
+// set of the not after metric.
+func newCertNotBeforeDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "certificate_cr", "not_before"),
+		"Timestamp before which the cert is not yet valid.",
+		[]string{
+			"name",
+			"namespace",
+			"issuer_ref",
+			"managed_issuer",
+		},
+		nil,
+	)
 }
 
 func DefaultConfig() Config {
 	return Config{
-		Namespaces: []string{},
+		ExcludedNamespaces: []string{},
+		Namespaces:         []string{},
 	}
 }
 
@@ -66,11 +119,14 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 
 	namespacesToCheck := []string{""}
 	// Create a list of namespaces to check.
-	if len(e.namespaces) != 0 {
-		namespacesToCheck = e.namespaces
+	if len(e.namespaces.Watch()) != 0 {
+		namespacesToCheck = e.namespaces.Watch()
 	}
 
-	listOptions := metav1.ListOptions{}
+	listOptions := metav1.ListOptions{
+		FieldSelector: e.fieldSelector,
+		LabelSelector: e.labelSelector,
+	}
 
 	// Loop over namespaces.
 	for _, namespace := range namespacesToCheck {
@@ -82,6 +138,14 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 			continue
 		}
 		for _, cert := range certs.Items {
+			if !e.namespaces.Contains(cert.GetNamespace()) {
+				continue
+			}
+			if scope.Ignored(&cert) {
+				e.logger.Log("debug", fmt.Sprintf("ignoring cert-manager certificate CR %s/%s annotated with %s", cert.GetNamespace(), cert.GetName(), scope.IgnoreAnnotation))
+				continue
+			}
+
 			notAfterStatusString, _, err := unstructured.NestedString(cert.UnstructuredContent(), "status", "notAfter")
 			if err != nil {
 				e.logger.Log("error", microerror.Mask(err))
@@ -116,6 +180,19 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 
 			ch <- prometheus.MustNewConstMetric(e.certNotAfter, prometheus.GaugeValue, notAfterUnix, certficateName, certificateNamespace, issuerRefName, isManaged)
 
+			// status.notBefore is only set once the certificate was issued.
+			notBeforeStatusString, found, err := unstructured.NestedString(cert.UnstructuredContent(), "status", "notBefore")
+			if err != nil {
+				e.logger.Log("error", microerror.Mask(err))
+			} else if found {
+				notBefore, err := time.Parse(time.RFC3339, notBeforeStatusString)
+				if err != nil {
+					e.logger.Log("error", microerror.Mask(err))
+				} else {
+					ch <- prometheus.MustNewConstMetric(e.certNotBefore, prometheus.GaugeValue, float64(notBefore.Unix()), certficateName, certificateNamespace, issuerRefName, isManaged)
+				}
+			}
+
 			e.logger.Log("info", fmt.Sprintf("added cert-manager certificate CR %s/%s to the metrics", certificateNamespace, certficateName))
 		}
 
@@ -126,6 +203,7 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 
 func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.certNotAfter
+	ch <- e.certNotBefore
 }
 
 func (e *Exporter) CheckIssuerManaged(name, namespace string) string {
@@ -172,6 +250,16 @@ func New(config Config) (*Exporter, error) {
 		return nil, err
 	}
 
+	_, err = labels.Parse(config.LabelSelector)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "label selector %#q: %s", config.LabelSelector, err)
+	}
+
+	_, err = fields.ParseSelector(config.FieldSelector)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "field selector %#q: %s", config.FieldSelector, err)
+	}
+
 	// Create k8s api client.
 	var restConfig *rest.Config
 	{
@@ -191,25 +279,36 @@ func New(config Config) (*Exporter, error) {
 		return nil, err
 	}
 
+	k8sClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := scope.NewNamespaces(scope.NamespacesConfig{
+		Client:        k8sClient,
+		Excluded:      config.ExcludedNamespaces,
+		LabelSelector: config.NamespaceLabelSelector,
+		Namespaces:    config.Namespaces,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
 	ctx := context.Background()
 
 	logger.Log("info", "creating new exporter")
 
+	// The namespaces are watched for the lifetime of the process.
+	namespaces.Start(ctx.Done())
+
 	return &Exporter{
-		certNotAfter: prometheus.NewDesc(
-			prometheus.BuildFQName("cert_exporter", "certificate_cr", "not_after"),
-			"Timestamp after which the cert is invalid.",
-			[]string{
-				"name",
-				"namespace",
-				"issuer_ref",
-				"managed_issuer",
-			},
-			nil,
-		),
+		certNotAfter:  newCertNotAfterDesc(),
+		certNotBefore: newCertNotBeforeDesc(),
 		ctx:           ctx,
 		dynamicClient: dynClient,
 		logger:        logger,
-		namespaces:    config.Namespaces,
+		fieldSelector: config.FieldSelector,
+		labelSelector: config.LabelSelector,
+		namespaces:    namespaces,
 	}, nil
 }
@@ -0,0 +1,124 @@
+package cr
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/giantswarm/cert-exporter/pkg/scope"
+)
+
+func newCertificate(namespace, name string, labels, annotations map[string]string) *unstructured.Unstructured {
+	cert := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"issuerRef": map[string]interface{}{
+				"kind": "ClusterIssuer",
+				"name": "letsencrypt",
+			},
+		},
+		"status": map[string]interface{}{
+			"notAfter": "2030-01-01T00:00:00Z",
+		},
+	}}
+	cert.SetLabels(labels)
+	cert.SetAnnotations(annotations)
+
+	return cert
+}
+
+// newTestExporter returns an exporter listing the given Certificates, in all
+// namespaces but the given excluded ones.
+func newTestExporter(t *testing.T, excluded []string, certs ...runtime.Object) *Exporter {
+	t.Helper()
+
+	logger, err := micrologger.New(micrologger.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			certManagerCertificateGroupVersionResource:   "CertificateList",
+			certManagerIssuerGroupVersionResource:        "IssuerList",
+			certManagerClusterIssuerGroupVersionResource: "ClusterIssuerList",
+		},
+		certs...,
+	)
+
+	namespaces, err := scope.NewNamespaces(scope.NamespacesConfig{Excluded: excluded})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Exporter{
+		certNotAfter:  newCertNotAfterDesc(),
+		certNotBefore: newCertNotBeforeDesc(),
+		ctx:           context.Background(),
+		dynamicClient: client,
+		logger:        logger,
+		namespaces:    namespaces,
+	}
+}
+
+// TestCollect_NotBefore covers status.notBefore, which is only set once the
+// certificate was issued.
+func TestCollect_NotBefore(t *testing.T) {
+	issued := newCertificate("default", "issued", nil, nil)
+	err := unstructured.SetNestedField(issued.Object, "2025-01-01T00:00:00Z", "status", "notBefore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := newCertificate("default", "pending", nil, nil)
+
+	e := newTestExporter(t, nil, issued, pending)
+
+	expected := `
+# HELP cert_exporter_certificate_cr_not_after Timestamp after which the cert is invalid.
+# TYPE cert_exporter_certificate_cr_not_after gauge
+cert_exporter_certificate_cr_not_after{issuer_ref="letsencrypt",managed_issuer="false",name="issued",namespace="default"} 1.893456e+09
+cert_exporter_certificate_cr_not_after{issuer_ref="letsencrypt",managed_issuer="false",name="pending",namespace="default"} 1.893456e+09
+# HELP cert_exporter_certificate_cr_not_before Timestamp before which the cert is not yet valid.
+# TYPE cert_exporter_certificate_cr_not_before gauge
+cert_exporter_certificate_cr_not_before{issuer_ref="letsencrypt",managed_issuer="false",name="issued",namespace="default"} 1.7356896e+09
+`
+	err = testutil.CollectAndCompare(e, strings.NewReader(expected), "cert_exporter_certificate_cr_not_after", "cert_exporter_certificate_cr_not_before")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCollect_Selectors covers Certificates left out by the label selector, by
+// the namespace exclusion list and Certificates opting out with the ignore
+// annotation.
+func TestCollect_Selectors(t *testing.T) {
+	e := newTestExporter(t, []string{"kube-system"},
+		newCertificate("default", "platform", map[string]string{"team": "platform"}, nil),
+		newCertificate("default", "other", map[string]string{"team": "other"}, nil),
+		newCertificate("default", "fixture", map[string]string{"team": "platform"}, map[string]string{scope.IgnoreAnnotation: "true"}),
+		newCertificate("kube-system", "excluded", map[string]string{"team": "platform"}, nil),
+	)
+	e.labelSelector = "team=platform"
+
+	expected := `
+# HELP cert_exporter_certificate_cr_not_after Timestamp after which the cert is invalid.
+# TYPE cert_exporter_certificate_cr_not_after gauge
+cert_exporter_certificate_cr_not_after{issuer_ref="letsencrypt",managed_issuer="false",name="platform",namespace="default"} 1.893456e+09
+`
+	err := testutil.CollectAndCompare(e, strings.NewReader(expected), "cert_exporter_certificate_cr_not_after")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
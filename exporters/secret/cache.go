@@ -0,0 +1,172 @@
+package secret
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// parsedSecret is what was read from a secret.
+type parsedSecret struct {
+	certName string
+	found    []foundCert
+}
+
+// cachedSecret is a parsed secret along with the resource version it was
+// parsed at. A secret whose resource version did not change since it was
+// parsed is served from the cache.
+type cachedSecret struct {
+	parsed          parsedSecret
+	resourceVersion string
+}
+
+// newSecretInformers returns an informer watching the secrets of each of the
+// given types in each of the given namespaces, or in the whole cluster if none
+// are given. Field selectors cannot select several types at once, so each type
+// is watched on its own. Secrets of every type are watched if no types are
+// given. The given label and field selectors restrict all informers, and the
+// given transform, if any, is applied to secrets before they are cached.
+func newSecretInformers(client kubernetes.Interface, namespaces []string, secretTypes []string, labelSelector, fieldSelector string, transform cache.TransformFunc) []coreinformers.SecretInformer {
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	if len(secretTypes) == 0 {
+		secretTypes = []string{""}
+	}
+
+	var secretInformers []coreinformers.SecretInformer
+	for _, namespace := range namespaces {
+		for _, secretType := range secretTypes {
+			informerFieldSelector := fieldSelector
+			if secretType != "" {
+				informerFieldSelector = joinFieldSelectors(fields.OneTermEqualSelector("type", secretType).String(), fieldSelector)
+			}
+			factory := informers.NewSharedInformerFactoryWithOptions(client, 0,
+				informers.WithNamespace(namespace),
+				informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+					options.FieldSelector = informerFieldSelector
+					options.LabelSelector = labelSelector
+				}),
+				informers.WithTransform(transform),
+			)
+
+			secretInformers = append(secretInformers, factory.Core().V1().Secrets())
+		}
+	}
+
+	return secretInformers
+}
+
+// joinFieldSelectors requires all of the given field selectors to match.
+// Empty ones are skipped.
+func joinFieldSelectors(selectors ...string) string {
+	var nonEmpty []string
+	for _, selector := range selectors {
+		if selector != "" {
+			nonEmpty = append(nonEmpty, selector)
+		}
+	}
+
+	return strings.Join(nonEmpty, ",")
+}
+
+// validateSelectors parses the given label and field selectors, so a typo
+// fails at startup instead of every list and watch.
+func validateSelectors(labelSelector, fieldSelector string) error {
+	_, err := labels.Parse(labelSelector)
+	if err != nil {
+		return microerror.Maskf(invalidConfigError, "label selector %#q: %s", labelSelector, err)
+	}
+
+	_, err = fields.ParseSelector(fieldSelector)
+	if err != nil {
+		return microerror.Maskf(invalidConfigError, "field selector %#q: %s", fieldSelector, err)
+	}
+
+	return nil
+}
+
+// startInformers starts watching secrets and CRL secrets until stop is
+// closed. Scrapes happening before an informer synced skip its secrets.
+func (e *Exporter) startInformers(stop <-chan struct{}) {
+	for _, informer := range e.informers {
+		go informer.Informer().Run(stop)
+	}
+	for _, c := range e.crlSecrets {
+		go c.informer.Informer().Run(stop)
+	}
+}
+
+// secrets returns the secrets held by the informers.
+func (e *Exporter) secrets() []*v1.Secret {
+	var secrets []*v1.Secret
+	for _, informer := range e.informers {
+		if !informer.Informer().HasSynced() {
+			e.logger.Log("warning", "secrets are not synced yet, skipping them")
+			continue
+		}
+
+		list, err := informer.Lister().List(labels.Everything())
+		if err != nil {
+			e.logger.Log("error", microerror.Mask(err))
+			continue
+		}
+
+		secrets = append(secrets, list...)
+	}
+
+	return secrets
+}
+
+// parsedSecretFor returns the parsed certificates of the given secret, from
+// the cache if the secret did not change since it was last parsed. Secrets
+// without a UID, which the API server always sets, are not cached.
+func (e *Exporter) parsedSecretFor(secret v1.Secret) parsedSecret {
+	if secret.UID == "" {
+		return e.parseSecret(secret)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	cached, ok := e.cache[secret.UID]
+	if ok && cached.resourceVersion == secret.ResourceVersion {
+		e.logger.Log("debug", fmt.Sprintf("using cached secret %s/%s", secret.Namespace, secret.Name))
+		return cached.parsed
+	}
+
+	parsed := e.parseSecret(secret)
+	if e.cache == nil {
+		e.cache = map[types.UID]cachedSecret{}
+	}
+	e.cache[secret.UID] = cachedSecret{
+		parsed:          parsed,
+		resourceVersion: secret.ResourceVersion,
+	}
+
+	return parsed
+}
+
+// pruneCache drops the parsed secrets that were not seen by the last scrape,
+// because they were deleted or stopped matching.
+func (e *Exporter) pruneCache(seen map[types.UID]bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for uid := range e.cache {
+		if !seen[uid] {
+			delete(e.cache, uid)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package secret
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/cert-exporter/pkg/scope"
+)
+
+// waitFor polls the given condition until it holds, failing the test after a
+// few seconds.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Fatal("condition not met in time")
+}
+
+// TestCollect_Informer covers scrapes served from the informer: a secret is
+// only parsed again once its resource version changed, and dropped from the
+// cache once deleted.
+func TestCollect_Informer(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "api-tls",
+			Namespace:       "default",
+			UID:             types.UID("1e5a3c1e-0000-4000-8000-000000000001"),
+			ResourceVersion: "1",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: map[string][]byte{"tls.crt": generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour))},
+	}
+	client := fake.NewClientset(secret)
+
+	e := newTestExporter(t)
+	e.k8sClient = client
+	e.informers = newSecretInformers(client, nil, []string{string(v1.SecretTypeTLS)}, "", "", nil)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	e.startInformers(stop)
+	waitFor(t, func() bool { return e.informers[0].Informer().HasSynced() })
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(e); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+	if got := len(samplesFor(body, "api-tls")); got != 1 {
+		t.Fatalf("expected 1 sample for the secret, got %d", got)
+	}
+
+	// A cached secret is served as parsed, even if its data was meanwhile
+	// swapped under the same resource version.
+	e.cache[secret.UID] = cachedSecret{resourceVersion: "1"}
+	_, body = serveMetrics(t, reg)
+	if got := len(samplesFor(body, "api-tls")); got != 0 {
+		t.Fatalf("expected the secret to be served from the cache, got %d samples", got)
+	}
+
+	updated := secret.DeepCopy()
+	updated.ResourceVersion = "2"
+	_, err := client.CoreV1().Secrets("default").Update(context.Background(), updated, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		cached, err := e.informers[0].Lister().Secrets("default").Get("api-tls")
+		return err == nil && cached.ResourceVersion == "2"
+	})
+
+	_, body = serveMetrics(t, reg)
+	if got := len(samplesFor(body, "api-tls")); got != 1 {
+		t.Fatalf("expected the updated secret to be parsed again, got %d samples", got)
+	}
+
+	err = client.CoreV1().Secrets("default").Delete(context.Background(), "api-tls", metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		list, _ := e.informers[0].Lister().List(labels.Everything())
+		return len(list) == 0
+	})
+
+	_, body = serveMetrics(t, reg)
+	if strings.Contains(body, `name="api-tls"`) {
+		t.Fatalf("expected the deleted secret not to be served in\n%s", body)
+	}
+	if len(e.cache) != 0 {
+		t.Fatalf("expected the deleted secret to be pruned from the cache, got %d entries", len(e.cache))
+	}
+}
+
+// TestCollect_Selectors covers secrets left out by the label selector, by the
+// namespace exclusion list and secrets opting out with the ignore annotation.
+func TestCollect_Selectors(t *testing.T) {
+	newSecret := func(namespace, name string, labels, annotations map[string]string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				UID:         types.UID(name),
+				Labels:      labels,
+				Annotations: annotations,
+			},
+			Type: v1.SecretTypeTLS,
+			Data: map[string][]byte{"tls.crt": generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour))},
+		}
+	}
+	client := fake.NewClientset(
+		newSecret("default", "platform-tls", map[string]string{"team": "platform"}, nil),
+		newSecret("default", "other-tls", map[string]string{"team": "other"}, nil),
+		newSecret("default", "fixture-tls", map[string]string{"team": "platform"}, map[string]string{scope.IgnoreAnnotation: "true"}),
+		newSecret("kube-system", "excluded-tls", map[string]string{"team": "platform"}, nil),
+	)
+
+	e := newTestExporter(t)
+	e.k8sClient = client
+	e.informers = newSecretInformers(client, nil, []string{string(v1.SecretTypeTLS)}, "team=platform", "", nil)
+
+	var err error
+	e.namespaces, err = scope.NewNamespaces(scope.NamespacesConfig{Excluded: []string{"kube-system"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	e.startInformers(stop)
+	waitFor(t, func() bool { return e.informers[0].Informer().HasSynced() })
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(e); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+	if got := len(samplesFor(body, "platform-tls")); got != 1 {
+		t.Fatalf("expected 1 sample for the selected secret, got %d in\n%s", got, body)
+	}
+	for _, name := range []string{"other-tls", "fixture-tls", "excluded-tls"} {
+		if strings.Contains(body, `name="`+name+`"`) {
+			t.Errorf("expected %s not to be served in\n%s", name, body)
+		}
+	}
+}
+
+func TestValidateSelectors(t *testing.T) {
+	if err := validateSelectors("team=platform", "metadata.name!=test"); err != nil {
+		t.Errorf("expected the selectors to be valid, got %v", err)
+	}
+	if err := validateSelectors("team in (platform", ""); !IsInvalidConfig(err) {
+		t.Errorf("expected a malformed label selector to be invalid, got %v", err)
+	}
+	if err := validateSelectors("", "metadata.name"); !IsInvalidConfig(err) {
+		t.Errorf("expected a malformed field selector to be invalid, got %v", err)
+	}
+}
+
+func TestJoinFieldSelectors(t *testing.T) {
+	if got := joinFieldSelectors("type=kubernetes.io/tls", ""); got != "type=kubernetes.io/tls" {
+		t.Errorf("expected the empty selector to be skipped, got %q", got)
+	}
+	if got := joinFieldSelectors("type=kubernetes.io/tls", "metadata.name!=test"); got != "type=kubernetes.io/tls,metadata.name!=test" {
+		t.Errorf("expected both selectors, got %q", got)
+	}
+}
@@ -0,0 +1,162 @@
+package secret
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/cert-exporter/pkg/revocation"
+)
+
+// parseCRLSecrets parses the configured CRL secrets, given as
+// <namespace>/<name>.
+func parseCRLSecrets(values []string) ([]types.NamespacedName, error) {
+	var refs []types.NamespacedName
+	for _, value := range values {
+		namespace, name, ok := strings.Cut(value, "/")
+		if !ok || namespace == "" || name == "" {
+			return nil, microerror.Maskf(invalidConfigError, "CRL secret %q must be given as <namespace>/<name>", value)
+		}
+
+		refs = append(refs, types.NamespacedName{Namespace: namespace, Name: name})
+	}
+
+	return refs, nil
+}
+
+// crlSecret is a secret holding CRLs, watched by an informer of its own as it
+// may be of any type and in any namespace.
+type crlSecret struct {
+	informer coreinformers.SecretInformer
+	ref      types.NamespacedName
+}
+
+// cachedCRLs are the CRLs parsed from a CRL secret, along with the resource
+// version they were parsed at.
+type cachedCRLs struct {
+	crls            []*x509.RevocationList
+	resourceVersion string
+}
+
+// newCRLSecrets returns the given CRL secrets, each with an informer watching
+// only that secret.
+func newCRLSecrets(client kubernetes.Interface, refs []types.NamespacedName) []crlSecret {
+	var crlSecrets []crlSecret
+	for _, ref := range refs {
+		fieldSelector := fields.OneTermEqualSelector("metadata.name", ref.Name).String()
+		factory := informers.NewSharedInformerFactoryWithOptions(client, 0,
+			informers.WithNamespace(ref.Namespace),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.FieldSelector = fieldSelector
+			}),
+		)
+
+		crlSecrets = append(crlSecrets, crlSecret{
+			informer: factory.Core().V1().Secrets(),
+			ref:      ref,
+		})
+	}
+
+	return crlSecrets
+}
+
+// revocationIndex reads the CRLs held by the configured CRL secrets. Any key
+// of such a secret may hold CRLs, as X509 CRL PEM blocks or binary DER. CRL
+// secrets are served from their informers and only parsed again once their
+// resource version changed.
+func (e *Exporter) revocationIndex() revocation.Index {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var crls []*x509.RevocationList
+	for _, c := range e.crlSecrets {
+		if !c.informer.Informer().HasSynced() {
+			e.logger.Log("warning", fmt.Sprintf("CRL secret %s is not synced yet, skipping it", c.ref))
+			continue
+		}
+
+		secret, err := c.informer.Lister().Secrets(c.ref.Namespace).Get(c.ref.Name)
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("could not read CRL secret %s: %s", c.ref, microerror.Mask(err)))
+			delete(e.crlCache, c.ref)
+			continue
+		}
+
+		cached, ok := e.crlCache[c.ref]
+		if !ok || cached.resourceVersion != secret.ResourceVersion {
+			cached = cachedCRLs{
+				crls:            e.parseCRLSecret(c.ref, secret),
+				resourceVersion: secret.ResourceVersion,
+			}
+			if e.crlCache == nil {
+				e.crlCache = map[types.NamespacedName]cachedCRLs{}
+			}
+			e.crlCache[c.ref] = cached
+		}
+
+		crls = append(crls, cached.crls...)
+	}
+
+	return revocation.NewIndex(crls)
+}
+
+func (e *Exporter) parseCRLSecret(ref types.NamespacedName, secret *v1.Secret) []*x509.RevocationList {
+	var crls []*x509.RevocationList
+	for key, data := range secret.Data {
+		parsed, err := revocation.ParseCRLs(data)
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("%s in CRL secret %s could not be parsed as a CRL: %s", key, ref, microerror.Mask(err)))
+		}
+
+		crls = append(crls, parsed...)
+	}
+
+	return crls
+}
+
+// exportRevoked reports whether the given certificate of a secret was revoked
+// by a CRL of its issuer. Certificates whose issuer has no CRL in the CRL
+// secrets are not reported on.
+func (e *Exporter) exportRevoked(ch chan<- prometheus.Metric, crls revocation.Index, f foundCert, secretName, secretNamespace, certName string) {
+	revoked, ok := crls.Status(f.cert)
+	if !ok {
+		return
+	}
+
+	serialNumber := fmt.Sprintf("%x", f.cert.SerialNumber)
+	value := 0.0
+	if revoked {
+		value = 1
+		e.logger.Log("warning", fmt.Sprintf("%s (%s) in secret %s/%s is revoked", f.key, serialNumber, secretNamespace, secretName))
+	}
+
+	ch <- prometheus.MustNewConstMetric(e.revoked, prometheus.GaugeValue, value, secretName, secretNamespace, f.key, certName, serialNumber, f.alias)
+}
+
+// newRevokedDesc describes whether a certificate was revoked by a CRL of its
+// issuer. It shares the labels of the not after metric.
+func newRevokedDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "secret", "revoked"),
+		"Whether the cert is revoked by a CRL of its issuer, 1 if it is and 0 otherwise.",
+		[]string{
+			"name",
+			"namespace",
+			"secretkey",
+			"certificatename",
+			"serialnumber",
+			"alias",
+		},
+		nil,
+	)
+}
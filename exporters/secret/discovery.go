@@ -0,0 +1,70 @@
+package secret
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// pemCertificateHeader starts every PEM certificate block.
+var pemCertificateHeader = []byte("-----BEGIN CERTIFICATE-----")
+
+// lastAppliedAnnotation holds a copy of the whole secret, data included, for
+// secrets managed with kubectl apply.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// discoveredCertKeys returns the keys of the given secret holding a PEM
+// certificate block which are not read otherwise, in lexical order. Values
+// larger than the discovery size cap are not inspected, so large blobs do not
+// slow scrapes down.
+func (e *Exporter) discoveredCertKeys(secret v1.Secret, certKeys []string) []string {
+	var keys []string
+	for key, value := range secret.Data {
+		if slices.Contains(certKeys, key) || slices.Contains(pkcs12Keys[:], key) || slices.Contains(jksKeys[:], key) {
+			continue
+		}
+
+		if e.discoveryMaxKeySize > 0 && len(value) > e.discoveryMaxKeySize {
+			e.logger.Log("debug", fmt.Sprintf("%s in secret %s/%s is larger than %d bytes, not inspecting it", key, secret.Namespace, secret.Name, e.discoveryMaxKeySize))
+			continue
+		}
+
+		if bytes.Contains(value, pemCertificateHeader) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// stripOversizedKeys returns the transform of the discovery informers. Those
+// watch every secret in scope, so it drops the values larger than the given
+// size cap which discovery would not inspect and nothing else reads, along
+// with the copy kubectl apply keeps of them, before they reach the informer
+// caches.
+func stripOversizedKeys(secretTypes map[string][]string, maxKeySize int) cache.TransformFunc {
+	return func(obj interface{}) (interface{}, error) {
+		secret, ok := obj.(*v1.Secret)
+		if !ok || maxKeySize <= 0 {
+			return obj, nil
+		}
+
+		patterns := secretTypes[string(secret.Type)]
+		for key, value := range secret.Data {
+			if len(value) <= maxKeySize || matchesAny(patterns, key) || slices.Contains(pkcs12Keys[:], key) || slices.Contains(jksKeys[:], key) {
+				continue
+			}
+
+			delete(secret.Data, key)
+		}
+		delete(secret.Annotations, lastAppliedAnnotation)
+		secret.ManagedFields = nil
+
+		return secret, nil
+	}
+}
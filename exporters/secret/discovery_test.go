@@ -0,0 +1,160 @@
+package secret
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestScrape_Discovery covers certificates hidden in keys of secrets whose
+// type and keys are not configured, which are only found with discovery.
+func TestScrape_Discovery(t *testing.T) {
+	_, key := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	large := append(generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour)), make([]byte, 2048)...)
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy-app", Namespace: "default"},
+		Type:       v1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"upstream":    generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour)),
+			"client_cert": generateSelfSignedCertPEM(t, time.Now().Add(48*time.Hour)),
+			"client_key":  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+			"blob":        large,
+			"config.yaml": []byte("endpoints: []"),
+		},
+	}
+
+	testCases := []struct {
+		name         string
+		discovery    bool
+		expectedKeys []string
+	}{
+		{
+			name:      "case 0: discovery disabled",
+			discovery: false,
+		},
+		{
+			name:         "case 1: discovery enabled",
+			discovery:    true,
+			expectedKeys: []string{"client_cert", "upstream"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newTestExporter(t)
+			e.discovery = tc.discovery
+			e.discoveryMaxKeySize = 1024
+
+			reg := prometheus.NewRegistry()
+			if err := reg.Register(&secretCollector{e: e, secret: secret}); err != nil {
+				t.Fatal(err)
+			}
+
+			_, body := serveMetrics(t, reg)
+
+			samples := samplesFor(body, "legacy-app")
+			if len(samples) != len(tc.expectedKeys) {
+				t.Fatalf("expected %d samples, got %d in\n%s", len(tc.expectedKeys), len(samples), body)
+			}
+			for _, key := range tc.expectedKeys {
+				if !strings.Contains(strings.Join(samples, "\n"), `secretkey="`+key+`"`) {
+					t.Errorf("expected a sample for %s in\n%s", key, body)
+				}
+			}
+		})
+	}
+}
+
+// TestScrape_DiscoveryKnownKeys makes sure keys already read through the
+// configured key patterns are not reported twice by discovery.
+func TestScrape_DiscoveryKnownKeys(t *testing.T) {
+	e := newTestExporter(t)
+	e.discovery = true
+	e.discoveryMaxKeySize = DefaultConfig().DiscoveryMaxKeySize
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ingress-tls", Namespace: "default"},
+		Type:       v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"tls.crt":      generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour)),
+			"previous.crt": generateSelfSignedCertPEM(t, time.Now().Add(48*time.Hour)),
+		},
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(&secretCollector{e: e, secret: secret}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	samples := samplesFor(body, "ingress-tls")
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 samples, got %d in\n%s", len(samples), body)
+	}
+}
+
+// TestInformer_DiscoveryStripsOversizedKeys makes sure the discovery informers
+// do not cache the values discovery skips for their size, while keeping the
+// ones read through the configured key patterns and keystores.
+func TestInformer_DiscoveryStripsOversizedKeys(t *testing.T) {
+	large := append(generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour)), make([]byte, 2048)...)
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "legacy-app",
+			Namespace:   "default",
+			Annotations: map[string]string{lastAppliedAnnotation: string(large)},
+		},
+		Type: v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"tls.crt":      large,
+			"keystore.p12": large,
+			"upstream":     generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour)),
+			"blob":         large,
+		},
+	}
+	client := fake.NewClientset(secret)
+
+	secretTypes := map[string][]string{string(v1.SecretTypeTLS): {"ca.crt", "tls.crt"}}
+	informers := newSecretInformers(client, nil, nil, "", "", stripOversizedKeys(secretTypes, 1024))
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go informers[0].Informer().Run(stop)
+	waitFor(t, func() bool { return informers[0].Informer().HasSynced() })
+
+	cached, err := informers[0].Lister().Secrets("default").Get("legacy-app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for key := range cached.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	expected := []string{"keystore.p12", "tls.crt", "upstream"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("expected cached keys %v, got %v", expected, keys)
+	}
+	if _, ok := cached.Annotations[lastAppliedAnnotation]; ok {
+		t.Fatalf("expected the %s annotation to be dropped", lastAppliedAnnotation)
+	}
+}
@@ -12,3 +12,12 @@ var certNotFoundError = &microerror.Error{
 func IsCertNotFound(err error) bool {
 	return microerror.Cause(err) == certNotFoundError
 }
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
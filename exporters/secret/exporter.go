@@ -5,6 +5,9 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/giantswarm/k8sclient/v8/pkg/k8srestconfig"
 	"github.com/giantswarm/microerror"
@@ -12,31 +15,114 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/giantswarm/cert-exporter/pkg/certinfo"
+	"github.com/giantswarm/cert-exporter/pkg/chain"
+	"github.com/giantswarm/cert-exporter/pkg/keystore"
+	"github.com/giantswarm/cert-exporter/pkg/policy"
+	"github.com/giantswarm/cert-exporter/pkg/revocation"
+	"github.com/giantswarm/cert-exporter/pkg/scope"
 )
 
-var certKeys = [2]string{"ca.crt", "tls.crt"}
-var listOpts = metav1.ListOptions{
-	FieldSelector: "type=kubernetes.io/tls",
+// tlsCertKeys are the keys of kubernetes.io/tls secrets holding certificates.
+var tlsCertKeys = []string{"ca.crt", "tls.crt"}
+
+// pkcs12Keys are the keys cert-manager stores PKCS#12 bundles under when
+// spec.keystores.pkcs12 is enabled on a Certificate. They are optional.
+var pkcs12Keys = [2]string{"keystore.p12", "truststore.p12"}
+
+// jksKeys are the keys cert-manager stores Java KeyStores under when
+// spec.keystores.jks is enabled on a Certificate. They are optional.
+var jksKeys = [2]string{"keystore.jks", "truststore.jks"}
+
+var certManagerCertificateGroupVersionResource = schema.GroupVersionResource{
+	Group:    "cert-manager.io",
+	Resource: "certificates",
+	Version:  "v1",
 }
 
 type Config struct {
-	Namespaces []string
+	// CRLSecrets are the secrets holding the CRLs to check certificates
+	// against, given as <namespace>/<name>.
+	CRLSecrets []string
+	// Discovery inspects every key of every secret in scope, whatever its
+	// type, for PEM certificates, on top of the keys given by SecretTypes.
+	Discovery bool
+	// ExcludedNamespaces are never read, whatever the other namespace fields
+	// select.
+	ExcludedNamespaces []string
+	// DiscoveryMaxKeySize is the size in bytes above which keys are not
+	// inspected by Discovery, 0 for unlimited.
+	DiscoveryMaxKeySize int
+	// FieldSelector restricts the secrets read, like metadata.name!=test.
+	// Only the metadata.name, metadata.namespace and type fields are
+	// supported by the API server.
+	FieldSelector string
+	// LabelSelector restricts the secrets read, like team=platform.
+	LabelSelector string
+	// NamespaceLabelSelector restricts the secrets read to the namespaces
+	// matching it, which are watched so new namespaces are picked up.
+	NamespaceLabelSelector string
+	Namespaces             []string
+	// OCSPChecker checks the OCSP status of certificates whose issuer is found
+	// in the same secret. OCSP is not checked if nil.
+	OCSPChecker *revocation.Checker
+	// PKCS12Passwords are tried in order to open PKCS#12 bundles, after the
+	// password referenced by the cert-manager Certificate owning the secret.
+	PKCS12Passwords []string
+	// Policy certificates are audited against. Certificates are not audited
+	// if nil.
+	Policy *policy.Policy
+	// SecretTypes are the types of the secrets to read, along with the globs
+	// of the keys holding certificates in secrets of each type.
+	SecretTypes map[string][]string
 }
 
 type Exporter struct {
-	cert      *prometheus.Desc
-	ctx       context.Context
-	k8sClient *kubernetes.Clientset
-	logger    micrologger.Logger
+	cert            *prometheus.Desc
+	chainNotAfter   *prometheus.Desc
+	chainValid      *prometheus.Desc
+	ctx             context.Context
+	dynamicClient   dynamic.Interface
+	info            *prometheus.Desc
+	k8sClient       kubernetes.Interface
+	logger          micrologger.Logger
+	notBefore       *prometheus.Desc
+	ocspStatus      *prometheus.Desc
+	policyViolation *prometheus.Desc
+	revoked         *prometheus.Desc
+	usageMismatch   *prometheus.Desc
+
+	// cache holds the parsed secrets by UID, informers the secrets they are
+	// parsed from. crlCache holds the parsed CRL secrets. mutex serializes
+	// concurrent scrapes using the caches.
+	cache     map[types.UID]cachedSecret
+	crlCache  map[types.NamespacedName]cachedCRLs
+	informers []coreinformers.SecretInformer
+	mutex     sync.Mutex
 
-	namespaces []string
+	crlSecrets          []crlSecret
+	discovery           bool
+	discoveryMaxKeySize int
+	namespaces          *scope.Namespaces
+	ocsp                *revocation.Checker
+	pkcs12Passwords     []string
+	policy              *policy.Policy
+	secretTypes         map[string][]string
 }
 
 // newCertDesc describes the exported metric. Kept separate from New so tests can
 // assert against the real label set instead of a copy of it. The serialnumber
-// label is what keeps concatenated certificates from colliding into one series.
+// label is what keeps concatenated certificates from colliding into one series,
+// the alias label tells the entries of a Java KeyStore apart.
 func newCertDesc() *prometheus.Desc {
 	return prometheus.NewDesc(
 		prometheus.BuildFQName("cert_exporter", "secret", "not_after"),
@@ -47,6 +133,79 @@ func newCertDesc() *prometheus.Desc {
 			"secretkey",
 			"certificatename",
 			"serialnumber",
+			"alias",
+		},
+		nil,
+	)
+}
+
+// newNotBeforeDesc describes the not before metric, which shares the label set
+// of the not after metric so both can be joined.
+func newNotBeforeDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "secret", "not_before"),
+		"Timestamp before which the cert is not yet valid.",
+		[]string{
+			"name",
+			"namespace",
+			"secretkey",
+			"certificatename",
+			"serialnumber",
+			"alias",
+		},
+		nil,
+	)
+}
+
+// newInfoDesc describes the certificate info metric, which is joinable to the
+// not after metric through all of its labels.
+func newInfoDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "secret", "certificate_info"),
+		"Information about the cert, always 1.",
+		append([]string{
+			"name",
+			"namespace",
+			"secretkey",
+			"certificatename",
+			"serialnumber",
+			"alias",
+		}, certinfo.LabelNames...),
+		nil,
+	)
+}
+
+// newChainValidDesc describes whether the chain of a leaf certificate verifies
+// against the CA certificates found in the same secret.
+func newChainValidDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "secret", "chain_valid"),
+		"Whether the cert chains to its issuer found in the same secret, 1 if it does and 0 otherwise.",
+		[]string{
+			"name",
+			"namespace",
+			"secretkey",
+			"certificatename",
+			"serialnumber",
+			"alias",
+		},
+		nil,
+	)
+}
+
+// newChainNotAfterDesc describes the earliest expiry of any certificate in the
+// chain of a leaf, which is when the leaf stops being usable.
+func newChainNotAfterDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "secret", "chain_not_after"),
+		"Timestamp after which any cert in the chain of the cert is invalid.",
+		[]string{
+			"name",
+			"namespace",
+			"secretkey",
+			"certificatename",
+			"serialnumber",
+			"alias",
 		},
 		nil,
 	)
@@ -54,42 +213,70 @@ func newCertDesc() *prometheus.Desc {
 
 func DefaultConfig() Config {
 	return Config{
-		Namespaces: []string{},
+		CRLSecrets:          []string{},
+		DiscoveryMaxKeySize: 64 * 1024,
+		ExcludedNamespaces:  []string{},
+		Namespaces:          []string{},
+		PKCS12Passwords:     []string{},
+		SecretTypes: map[string][]string{
+			string(v1.SecretTypeTLS): tlsCertKeys,
+		},
 	}
 }
 
 func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 	e.logger.Log("info", "start collecting metrics")
 
-	namespacesToCheck := []string{""}
-	// Create a list of namespaces to check.
-	if len(e.namespaces) != 0 {
-		namespacesToCheck = e.namespaces
-	}
+	crls := e.revocationIndex()
 
-	clusterSecrets := []v1.Secret{}
-	// Loop over namespaces
-	for _, namespace := range namespacesToCheck {
-		secrets, err := e.k8sClient.CoreV1().Secrets(namespace).List(e.ctx, listOpts)
-		if err != nil {
-			e.logger.Log("error", microerror.Mask(err))
+	// Secrets are served from the informers, only the ones that changed
+	// since the previous scrape are parsed again.
+	seen := map[types.UID]bool{}
+	for _, secret := range e.secrets() {
+		if !e.namespaces.Contains(secret.Namespace) {
+			continue
+		}
+		if scope.Ignored(secret) {
+			e.logger.Log("debug", fmt.Sprintf("ignoring secret %s/%s annotated with %s", secret.Namespace, secret.Name, scope.IgnoreAnnotation))
+			continue
 		}
 
-		clusterSecrets = append(clusterSecrets, secrets.Items...)
-	}
-
-	// Loop over discovered secrets
-	for _, secret := range clusterSecrets {
-		err := e.calculateExpiry(ch, secret)
+		seen[secret.UID] = true
+		err := e.calculateExpiry(ch, *secret, crls)
 		if err != nil {
 			e.logger.Log("error", microerror.Mask(err))
 		}
 	}
+	e.pruneCache(seen)
 
 	e.logger.Log("info", "finished collecting metrics")
 }
 
-func (e *Exporter) calculateExpiry(ch chan<- prometheus.Metric, secret v1.Secret) error {
+func (e *Exporter) calculateExpiry(ch chan<- prometheus.Metric, secret v1.Secret, crls revocation.Index) error {
+	secretName := secret.Name
+	secretNamespace := secret.Namespace
+	parsed := e.parsedSecretFor(secret)
+	certName := parsed.certName
+	found := parsed.found
+
+	for _, f := range found {
+		e.exportCerts(ch, []*x509.Certificate{f.cert}, secretName, secretNamespace, f.key, certName, f.alias)
+		e.exportRevoked(ch, crls, f, secretName, secretNamespace, certName)
+	}
+	e.exportChains(ch, found, secretName, secretNamespace, certName)
+	e.exportOCSP(ch, found, secretName, secretNamespace, certName)
+	e.exportPolicyViolations(ch, found, secretName, secretNamespace, certName)
+	e.exportUsageMismatches(ch, found, secretName, secretNamespace, certName, secret.Annotations[roleAnnotation])
+
+	e.logger.Log("info", fmt.Sprintf("added secret %s/%s to the metrics", secretNamespace, secretName))
+
+	return nil
+}
+
+// parseSecret reads the certificates held by the given secret, from its PEM
+// or PKCS#7 cert keys, PKCS#12 bundles and Java KeyStores. With discovery, any
+// other key holding a PEM certificate is read too.
+func (e *Exporter) parseSecret(secret v1.Secret) parsedSecret {
 	secretName := secret.Name
 	secretNamespace := secret.Namespace
 	var certName string
@@ -97,10 +284,25 @@ func (e *Exporter) calculateExpiry(ch chan<- prometheus.Metric, secret v1.Secret
 		certName = secret.Annotations["cert-manager.io/certificate-name"]
 	}
 
+	certKeys := e.certKeysFor(secret)
+	if e.discovery {
+		certKeys = append(certKeys, e.discoveredCertKeys(secret, certKeys)...)
+	}
+
+	var found []foundCert
 	for _, certKey := range certKeys {
-		certBytes, ok := secret.Data[certKey]
-		if !ok {
-			e.logger.Log("error", microerror.Maskf(certNotFoundError, "secret %s/%s contains no key matching '%s'", secretNamespace, secretName, certKey))
+		certBytes := secret.Data[certKey]
+
+		// Intermediate chains are sometimes handed out as DER PKCS#7 bundles
+		// and stored as is.
+		if keystore.IsPKCS7(certBytes) {
+			certs, err := keystore.DecodePKCS7(certBytes)
+			if err != nil {
+				e.logger.Log("warning", fmt.Sprintf("%s in secret %s/%s could not be read as a PKCS#7 bundle: %s", certKey, secretName, secretNamespace, microerror.Mask(err)))
+				continue
+			}
+
+			found = appendFound(found, certs, certKey, "")
 			continue
 		}
 
@@ -112,26 +314,197 @@ func (e *Exporter) calculateExpiry(ch chan<- prometheus.Metric, secret v1.Secret
 			}
 			rest = remaining
 
+			// Keys matched by globs, like *.pem, may hold private keys.
+			if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+				continue
+			}
+
+			if keystore.IsPKCS7Block(block) {
+				certs, err := keystore.DecodePKCS7(block.Bytes)
+				if err != nil {
+					e.logger.Log("warning", fmt.Sprintf("%s in secret %s/%s could not be read as a PKCS#7 bundle: %s", certKey, secretName, secretNamespace, microerror.Mask(err)))
+					continue
+				}
+
+				found = appendFound(found, certs, certKey, "")
+				continue
+			}
+
 			certs, err := x509.ParseCertificates(block.Bytes)
 			if err != nil {
 				e.logger.Log("warning", fmt.Sprintf("%s in secret %s/%s could not be parsed as a certificate: %s", certKey, secretName, secretNamespace, microerror.Mask(err)))
 				continue
 			}
 
-			for _, cert := range certs {
-				timestamp := float64(cert.NotAfter.Unix())
-				serialNumber := fmt.Sprintf("%x", cert.SerialNumber)
-				ch <- prometheus.MustNewConstMetric(e.cert, prometheus.GaugeValue, timestamp, secretName, secretNamespace, certKey, certName, serialNumber)
-			}
+			found = appendFound(found, certs, certKey, "")
 		}
 	}
-	e.logger.Log("info", fmt.Sprintf("added secret %s/%s to the metrics", secretNamespace, secretName))
 
-	return nil
+	var passwords []string
+	for _, pkcs12Key := range pkcs12Keys {
+		bundle, ok := secret.Data[pkcs12Key]
+		if !ok {
+			continue
+		}
+
+		if passwords == nil {
+			passwords = e.pkcs12PasswordsFor(secretNamespace, certName)
+		}
+
+		certs, err := keystore.DecodePKCS12(bundle, passwords)
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("%s in secret %s/%s could not be opened as a PKCS#12 bundle: %s", pkcs12Key, secretName, secretNamespace, microerror.Mask(err)))
+			continue
+		}
+
+		found = appendFound(found, certs, pkcs12Key, "")
+	}
+
+	for _, jksKey := range jksKeys {
+		store, ok := secret.Data[jksKey]
+		if !ok {
+			continue
+		}
+
+		entries, err := keystore.DecodeJKS(store)
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("%s in secret %s/%s could not be read as a Java KeyStore: %s", jksKey, secretName, secretNamespace, microerror.Mask(err)))
+		}
+
+		for _, entry := range entries {
+			found = appendFound(found, []*x509.Certificate{entry.Certificate}, jksKey, entry.Alias)
+		}
+	}
+
+	return parsedSecret{
+		certName: certName,
+		found:    found,
+	}
+}
+
+// foundCert is a certificate found in a secret, along with the key and the
+// Java KeyStore alias it was found under.
+type foundCert struct {
+	alias string
+	cert  *x509.Certificate
+	key   string
+}
+
+func appendFound(found []foundCert, certs []*x509.Certificate, key, alias string) []foundCert {
+	for _, cert := range certs {
+		found = append(found, foundCert{alias: alias, cert: cert, key: key})
+	}
+
+	return found
+}
+
+func (e *Exporter) exportCerts(ch chan<- prometheus.Metric, certs []*x509.Certificate, secretName, secretNamespace, certKey, certName, alias string) {
+	for _, cert := range certs {
+		serialNumber := fmt.Sprintf("%x", cert.SerialNumber)
+		ch <- prometheus.MustNewConstMetric(e.cert, prometheus.GaugeValue, float64(cert.NotAfter.Unix()), secretName, secretNamespace, certKey, certName, serialNumber, alias)
+		ch <- prometheus.MustNewConstMetric(e.notBefore, prometheus.GaugeValue, float64(cert.NotBefore.Unix()), secretName, secretNamespace, certKey, certName, serialNumber, alias)
+		ch <- prometheus.MustNewConstMetric(e.info, prometheus.GaugeValue, 1, append([]string{secretName, secretNamespace, certKey, certName, serialNumber, alias}, certinfo.Labels(cert)...)...)
+	}
+}
+
+// exportChains verifies the chain of every leaf certificate of a secret
+// against the CA certificates found in it, like the ca.crt cert-manager writes.
+// Leaves whose issuer is not in the secret are not reported on.
+func (e *Exporter) exportChains(ch chan<- prometheus.Metric, found []foundCert, secretName, secretNamespace, certName string) {
+	var candidates []*x509.Certificate
+	for _, f := range found {
+		candidates = append(candidates, f.cert)
+	}
+
+	now := time.Now()
+	for _, f := range found {
+		if !chain.IsLeaf(f.cert) {
+			continue
+		}
+
+		result, ok := chain.Verify(f.cert, candidates, now)
+		if !ok {
+			continue
+		}
+
+		serialNumber := fmt.Sprintf("%x", f.cert.SerialNumber)
+		valid := 0.0
+		if result.Valid() {
+			valid = 1
+		} else {
+			e.logger.Log("warning", fmt.Sprintf("%s (%s) in secret %s/%s does not chain to its issuer: %s", f.key, serialNumber, secretNamespace, secretName, result.Err))
+		}
+
+		ch <- prometheus.MustNewConstMetric(e.chainValid, prometheus.GaugeValue, valid, secretName, secretNamespace, f.key, certName, serialNumber, f.alias)
+		ch <- prometheus.MustNewConstMetric(e.chainNotAfter, prometheus.GaugeValue, float64(result.NotAfter().Unix()), secretName, secretNamespace, f.key, certName, serialNumber, f.alias)
+	}
+}
+
+// pkcs12PasswordsFor returns the passwords to try for the PKCS#12 bundles of a
+// secret: the password configured in spec.keystores.pkcs12 of the cert-manager
+// Certificate owning it first, then the configured passwords and finally the
+// empty password.
+func (e *Exporter) pkcs12PasswordsFor(namespace, certName string) []string {
+	var passwords []string
+	if certName != "" && e.dynamicClient != nil {
+		password, err := e.certificatePKCS12Password(namespace, certName)
+		if err != nil {
+			e.logger.Log("warning", fmt.Sprintf("could not look up the PKCS#12 password of certificate %s/%s: %s", namespace, certName, microerror.Mask(err)))
+		} else if password != "" {
+			passwords = append(passwords, password)
+		}
+	}
+
+	passwords = append(passwords, e.pkcs12Passwords...)
+
+	return append(passwords, "")
+}
+
+// certificatePKCS12Password resolves the PKCS#12 password of a cert-manager
+// Certificate, either given literally or through spec.keystores.pkcs12.passwordSecretRef.
+func (e *Exporter) certificatePKCS12Password(namespace, certName string) (string, error) {
+	cert, err := e.dynamicClient.Resource(certManagerCertificateGroupVersionResource).Namespace(namespace).Get(e.ctx, certName, metav1.GetOptions{})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	password, found, err := unstructured.NestedString(cert.UnstructuredContent(), "spec", "keystores", "pkcs12", "password")
+	if err != nil {
+		return "", microerror.Mask(err)
+	} else if found {
+		return password, nil
+	}
+
+	refName, _, err := unstructured.NestedString(cert.UnstructuredContent(), "spec", "keystores", "pkcs12", "passwordSecretRef", "name")
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	refKey, _, err := unstructured.NestedString(cert.UnstructuredContent(), "spec", "keystores", "pkcs12", "passwordSecretRef", "key")
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	if refName == "" || refKey == "" {
+		return "", nil
+	}
+
+	passwordSecret, err := e.k8sClient.CoreV1().Secrets(namespace).Get(e.ctx, refName, metav1.GetOptions{})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return string(passwordSecret.Data[refKey]), nil
 }
 
 func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.cert
+	ch <- e.chainNotAfter
+	ch <- e.chainValid
+	ch <- e.info
+	ch <- e.notBefore
+	ch <- e.ocspStatus
+	ch <- e.policyViolation
+	ch <- e.revoked
+	ch <- e.usageMismatch
 }
 
 func New(config Config) (*Exporter, error) {
@@ -140,6 +513,21 @@ func New(config Config) (*Exporter, error) {
 		return nil, err
 	}
 
+	crlSecrets, err := parseCRLSecrets(config.CRLSecrets)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = validateSecretTypes(config.SecretTypes)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = validateSelectors(config.LabelSelector, config.FieldSelector)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
 	// Create k8s api client
 	var restConfig *rest.Config
 	{
@@ -161,15 +549,65 @@ func New(config Config) (*Exporter, error) {
 		return nil, err
 	}
 
+	dynClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := scope.NewNamespaces(scope.NamespacesConfig{
+		Client:        k8sClient,
+		Excluded:      config.ExcludedNamespaces,
+		LabelSelector: config.NamespaceLabelSelector,
+		Namespaces:    config.Namespaces,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// Discovery inspects secrets of every type, so it keeps the large values
+	// it skips out of memory.
+	informerSecretTypes := secretTypesOf(config.SecretTypes)
+	var informerTransform cache.TransformFunc
+	if config.Discovery {
+		informerSecretTypes = nil
+		informerTransform = stripOversizedKeys(config.SecretTypes, config.DiscoveryMaxKeySize)
+	}
+
 	ctx := context.Background()
 
 	logger.Log("info", "creating new exporter")
 
-	return &Exporter{
-		cert:       newCertDesc(),
-		ctx:        ctx,
-		k8sClient:  k8sClient,
-		logger:     logger,
-		namespaces: config.Namespaces,
-	}, nil
+	e := &Exporter{
+		cert:            newCertDesc(),
+		chainNotAfter:   newChainNotAfterDesc(),
+		chainValid:      newChainValidDesc(),
+		ctx:             ctx,
+		dynamicClient:   dynClient,
+		info:            newInfoDesc(),
+		k8sClient:       k8sClient,
+		logger:          logger,
+		notBefore:       newNotBeforeDesc(),
+		ocspStatus:      newOCSPStatusDesc(),
+		policyViolation: newPolicyViolationDesc(),
+		revoked:         newRevokedDesc(),
+		usageMismatch:   newUsageMismatchDesc(),
+
+		informers: newSecretInformers(k8sClient, namespaces.Watch(), informerSecretTypes, config.LabelSelector, config.FieldSelector, informerTransform),
+
+		crlSecrets:          newCRLSecrets(k8sClient, crlSecrets),
+		discovery:           config.Discovery,
+		discoveryMaxKeySize: config.DiscoveryMaxKeySize,
+		namespaces:          namespaces,
+		ocsp:                config.OCSPChecker,
+		pkcs12Passwords:     config.PKCS12Passwords,
+		policy:              config.Policy,
+		secretTypes:         config.SecretTypes,
+	}
+
+	// The informers watch secrets and namespaces for the lifetime of the
+	// process.
+	e.startInformers(ctx.Done())
+	namespaces.Start(ctx.Done())
+
+	return e, nil
 }
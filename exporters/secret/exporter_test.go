@@ -7,6 +7,7 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
@@ -19,6 +20,16 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"software.sslmate.com/src/go-pkcs12"
+
+	"github.com/giantswarm/cert-exporter/pkg/certtest"
+	"github.com/giantswarm/cert-exporter/pkg/keystore/keystoretest"
+	"github.com/giantswarm/cert-exporter/pkg/revocation"
+	"github.com/giantswarm/cert-exporter/pkg/scope"
 )
 
 const metricName = "cert_exporter_secret_not_after"
@@ -31,16 +42,32 @@ func newTestExporter(t *testing.T) *Exporter {
 		t.Fatal(err)
 	}
 
+	namespaces, err := scope.NewNamespaces(scope.NamespacesConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	return &Exporter{
 		// The production descriptor, so a change to the exported labels is
 		// caught here instead of silently passing against a copy.
-		cert:   newCertDesc(),
-		ctx:    context.Background(),
-		logger: logger,
+		cert:            newCertDesc(),
+		chainNotAfter:   newChainNotAfterDesc(),
+		chainValid:      newChainValidDesc(),
+		ctx:             context.Background(),
+		info:            newInfoDesc(),
+		logger:          logger,
+		notBefore:       newNotBeforeDesc(),
+		ocspStatus:      newOCSPStatusDesc(),
+		policyViolation: newPolicyViolationDesc(),
+		revoked:         newRevokedDesc(),
+		usageMismatch:   newUsageMismatchDesc(),
+
+		namespaces:  namespaces,
+		secretTypes: DefaultConfig().SecretTypes,
 	}
 }
 
-func generateSelfSignedCertPEM(t *testing.T, notAfter time.Time) []byte {
+func generateSelfSignedCert(t *testing.T, notAfter time.Time) (*x509.Certificate, *ecdsa.PrivateKey) {
 	t.Helper()
 
 	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
@@ -61,7 +88,20 @@ func generateSelfSignedCertPEM(t *testing.T, notAfter time.Time) []byte {
 		t.Fatal(err)
 	}
 
-	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+func generateSelfSignedCertPEM(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+
+	cert, _ := generateSelfSignedCert(t, notAfter)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
 }
 
 func TestCalculateExpiry_SingleCert(t *testing.T) {
@@ -75,14 +115,15 @@ func TestCalculateExpiry_SingleCert(t *testing.T) {
 			Name:      "test-secret",
 			Namespace: "default",
 		},
+		Type: v1.SecretTypeTLS,
 		Data: map[string][]byte{
 			"tls.crt": certPEM,
 			"ca.crt":  certPEM,
 		},
 	}
 
-	ch := make(chan prometheus.Metric, 10)
-	err := e.calculateExpiry(ch, secret)
+	ch := make(chan prometheus.Metric, 100)
+	err := e.calculateExpiry(ch, secret, revocation.Index{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -90,7 +131,10 @@ func TestCalculateExpiry_SingleCert(t *testing.T) {
 
 	var metrics []prometheus.Metric
 	for m := range ch {
-		metrics = append(metrics, m)
+		// Only count the not after metric, not the ones emitted alongside it.
+		if m.Desc() == e.cert {
+			metrics = append(metrics, m)
+		}
 	}
 
 	// One metric per key (ca.crt + tls.crt)
@@ -115,14 +159,15 @@ func TestCalculateExpiry_MultipleCertsInSameKey(t *testing.T) {
 			Name:      "kyverno-webhook",
 			Namespace: "kyverno",
 		},
+		Type: v1.SecretTypeTLS,
 		Data: map[string][]byte{
 			"tls.crt": combined,
 			"ca.crt":  generateSelfSignedCertPEM(t, notAfter2),
 		},
 	}
 
-	ch := make(chan prometheus.Metric, 10)
-	err := e.calculateExpiry(ch, secret)
+	ch := make(chan prometheus.Metric, 100)
+	err := e.calculateExpiry(ch, secret, revocation.Index{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -130,7 +175,10 @@ func TestCalculateExpiry_MultipleCertsInSameKey(t *testing.T) {
 
 	var metrics []prometheus.Metric
 	for m := range ch {
-		metrics = append(metrics, m)
+		// Only count the not after metric, not the ones emitted alongside it.
+		if m.Desc() == e.cert {
+			metrics = append(metrics, m)
+		}
 	}
 
 	// tls.crt has 2 certs, ca.crt has 1 = 3 total
@@ -143,12 +191,15 @@ func TestCalculateExpiry_MultipleCertsInSameKey(t *testing.T) {
 // so the metrics can be exercised through a real registry Gather(), which is
 // where duplicate-series collisions surface (a plain channel read does not).
 type secretCollector struct {
+	crls   revocation.Index
 	e      *Exporter
 	secret v1.Secret
 }
 
-func (c *secretCollector) Describe(ch chan<- *prometheus.Desc) { ch <- c.e.cert }
-func (c *secretCollector) Collect(ch chan<- prometheus.Metric) { _ = c.e.calculateExpiry(ch, c.secret) }
+func (c *secretCollector) Describe(ch chan<- *prometheus.Desc) { c.e.Describe(ch) }
+func (c *secretCollector) Collect(ch chan<- prometheus.Metric) {
+	_ = c.e.calculateExpiry(ch, c.secret, c.crls)
+}
 
 // multiSecretCollector collects several secrets in one scrape, so a collision
 // caused by one secret can be observed against the metrics of the others.
@@ -157,10 +208,10 @@ type multiSecretCollector struct {
 	secrets []v1.Secret
 }
 
-func (c *multiSecretCollector) Describe(ch chan<- *prometheus.Desc) { ch <- c.e.cert }
+func (c *multiSecretCollector) Describe(ch chan<- *prometheus.Desc) { c.e.Describe(ch) }
 func (c *multiSecretCollector) Collect(ch chan<- prometheus.Metric) {
 	for _, s := range c.secrets {
-		_ = c.e.calculateExpiry(ch, s)
+		_ = c.e.calculateExpiry(ch, s, revocation.Index{})
 	}
 }
 
@@ -176,6 +227,7 @@ func TestGather_MultipleCertsInSameKey(t *testing.T) {
 
 	secret := v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{Name: "kyverno-webhook", Namespace: "kyverno"},
+		Type:       v1.SecretTypeTLS,
 		Data:       map[string][]byte{"tls.crt": combined},
 	}
 
@@ -191,7 +243,9 @@ func TestGather_MultipleCertsInSameKey(t *testing.T) {
 
 	var series int
 	for _, mf := range mfs {
-		series += len(mf.GetMetric())
+		if mf.GetName() == metricName {
+			series += len(mf.GetMetric())
+		}
 	}
 	if series != 2 {
 		t.Fatalf("expected 2 distinct series for concatenated certs, got %d", series)
@@ -235,6 +289,7 @@ func TestScrape_DuplicateIdenticalCertInSameKey(t *testing.T) {
 
 	secret := v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{Name: "duplicated-chain", Namespace: "default"},
+		Type:       v1.SecretTypeTLS,
 		Data:       map[string][]byte{"tls.crt": duplicated},
 	}
 
@@ -263,6 +318,7 @@ func TestGather_SameCertInBothKeys(t *testing.T) {
 
 	secret := v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{Name: "shared-ca", Namespace: "default"},
+		Type:       v1.SecretTypeTLS,
 		Data: map[string][]byte{
 			"tls.crt": certPEM,
 			"ca.crt":  certPEM,
@@ -281,7 +337,9 @@ func TestGather_SameCertInBothKeys(t *testing.T) {
 
 	var series int
 	for _, mf := range mfs {
-		series += len(mf.GetMetric())
+		if mf.GetName() == metricName {
+			series += len(mf.GetMetric())
+		}
 	}
 	if series != 2 {
 		t.Fatalf("expected 1 series per secret key, got %d", series)
@@ -299,6 +357,7 @@ func TestGather_LeafPlusCA(t *testing.T) {
 
 	secret := v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{Name: "leaf-and-ca", Namespace: "default"},
+		Type:       v1.SecretTypeTLS,
 		Data:       map[string][]byte{"tls.crt": append(append([]byte{}, leaf...), ca...)},
 	}
 
@@ -314,7 +373,9 @@ func TestGather_LeafPlusCA(t *testing.T) {
 
 	var series int
 	for _, mf := range mfs {
-		series += len(mf.GetMetric())
+		if mf.GetName() == metricName {
+			series += len(mf.GetMetric())
+		}
 	}
 	if series != 2 {
 		t.Fatalf("expected 2 distinct series (leaf + CA), got %d", series)
@@ -334,10 +395,12 @@ func TestScrape_DuplicateDoesNotHideOtherSecrets(t *testing.T) {
 	secrets := []v1.Secret{
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "healthy", Namespace: "default"},
+			Type:       v1.SecretTypeTLS,
 			Data:       map[string][]byte{"tls.crt": healthy},
 		},
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "duplicated", Namespace: "default"},
+			Type:       v1.SecretTypeTLS,
 			Data:       map[string][]byte{"tls.crt": append(append([]byte{}, broken...), broken...)},
 		},
 	}
@@ -365,14 +428,15 @@ func TestCalculateExpiry_MissingKey(t *testing.T) {
 			Name:      "incomplete-secret",
 			Namespace: "default",
 		},
+		Type: v1.SecretTypeTLS,
 		Data: map[string][]byte{
 			"tls.crt": generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour)),
 			// ca.crt is missing
 		},
 	}
 
-	ch := make(chan prometheus.Metric, 10)
-	err := e.calculateExpiry(ch, secret)
+	ch := make(chan prometheus.Metric, 100)
+	err := e.calculateExpiry(ch, secret, revocation.Index{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -380,10 +444,404 @@ func TestCalculateExpiry_MissingKey(t *testing.T) {
 
 	var metrics []prometheus.Metric
 	for m := range ch {
-		metrics = append(metrics, m)
+		// Only count the not after metric, not the ones emitted alongside it.
+		if m.Desc() == e.cert {
+			metrics = append(metrics, m)
+		}
 	}
 
 	if len(metrics) != 1 {
 		t.Fatalf("expected 1 metric (only tls.crt), got %d", len(metrics))
 	}
 }
+
+// TestCalculateExpiry_PKCS12 covers the keystore.p12 and truststore.p12 keys
+// cert-manager writes, opened with the password its Certificate references.
+func TestCalculateExpiry_PKCS12(t *testing.T) {
+	leaf, key := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+	ca, _ := generateSelfSignedCert(t, time.Now().Add(48*time.Hour))
+
+	keystoreP12, err := pkcs12.Modern.Encode(key, leaf, []*x509.Certificate{ca}, "from-secret-ref")
+	if err != nil {
+		t.Fatal(err)
+	}
+	truststoreP12, err := pkcs12.Modern.EncodeTrustStore([]*x509.Certificate{ca}, "from-secret-ref")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificate := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata": map[string]interface{}{
+			"name":      "kafka",
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"keystores": map[string]interface{}{
+				"pkcs12": map[string]interface{}{
+					"create": true,
+					"passwordSecretRef": map[string]interface{}{
+						"name": "kafka-keystore-password",
+						"key":  "password",
+					},
+				},
+			},
+		},
+	}}
+	passwordSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka-keystore-password", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("from-secret-ref")},
+	}
+
+	e := newTestExporter(t)
+	e.dynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), certificate)
+	e.k8sClient = fake.NewClientset(passwordSecret)
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "kafka-tls",
+			Namespace:   "default",
+			Annotations: map[string]string{"cert-manager.io/certificate-name": "kafka"},
+		},
+		Type: v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"tls.crt":        pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw}),
+			"keystore.p12":   keystoreP12,
+			"truststore.p12": truststoreP12,
+		},
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(&secretCollector{e: e, secret: secret}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	counts := map[string]int{}
+	for _, sample := range samplesFor(body, "kafka-tls") {
+		for _, key := range []string{"tls.crt", "keystore.p12", "truststore.p12"} {
+			if strings.Contains(sample, `secretkey="`+key+`"`) {
+				counts[key]++
+			}
+		}
+	}
+
+	expected := map[string]int{"tls.crt": 1, "keystore.p12": 2, "truststore.p12": 1}
+	for key, count := range expected {
+		if counts[key] != count {
+			t.Fatalf("expected %d samples for %s, got %d", count, key, counts[key])
+		}
+	}
+}
+
+// TestCalculateExpiry_PKCS7 covers PKCS#7 bundles stored in a secret, either
+// as binary DER or as a PEM block.
+func TestCalculateExpiry_PKCS7(t *testing.T) {
+	leaf, _ := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+	intermediate, _ := generateSelfSignedCert(t, time.Now().Add(48*time.Hour))
+	ca, _ := generateSelfSignedCert(t, time.Now().Add(72*time.Hour))
+
+	chain := keystoretest.EncodePKCS7(t, []*x509.Certificate{intermediate, ca})
+	leafBundle := keystoretest.EncodePKCS7(t, []*x509.Certificate{leaf})
+
+	e := newTestExporter(t)
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vendor-tls", Namespace: "default"},
+		Type:       v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"ca.crt":  chain,
+			"tls.crt": pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: leafBundle}),
+		},
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(&secretCollector{e: e, secret: secret}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	counts := map[string]int{}
+	for _, sample := range samplesFor(body, "vendor-tls") {
+		for _, key := range tlsCertKeys {
+			if strings.Contains(sample, `secretkey="`+key+`"`) {
+				counts[key]++
+			}
+		}
+	}
+
+	expected := map[string]int{"ca.crt": 2, "tls.crt": 1}
+	for key, count := range expected {
+		if counts[key] != count {
+			t.Fatalf("expected %d samples for %s, got %d", count, key, counts[key])
+		}
+	}
+}
+
+// TestScrape_JKSAliases covers the truststore.jks key cert-manager writes:
+// every alias is served as its own series, labelled with the alias.
+func TestScrape_JKSAliases(t *testing.T) {
+	e := newTestExporter(t)
+
+	ca, _ := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+	other, _ := generateSelfSignedCert(t, time.Now().Add(48*time.Hour))
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "zookeeper-tls", Namespace: "default"},
+		Type:       v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"truststore.jks": keystoretest.EncodeTrustStoreJKS(t, map[string]*x509.Certificate{
+				"ca":    ca,
+				"other": other,
+			}),
+		},
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(&secretCollector{e: e, secret: secret}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	samples := samplesFor(body, "zookeeper-tls")
+	if len(samples) != 2 {
+		t.Fatalf("expected one sample per alias, got %d", len(samples))
+	}
+	for _, alias := range []string{"ca", "other"} {
+		var found bool
+		for _, sample := range samples {
+			if strings.Contains(sample, `alias="`+alias+`"`) && strings.Contains(sample, `secretkey="truststore.jks"`) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("expected a sample with alias %q", alias)
+		}
+	}
+}
+
+// TestScrape_NotBefore makes sure every certificate is also served as a not
+// before sample carrying the same labels as its not after sample.
+func TestScrape_NotBefore(t *testing.T) {
+	e := newTestExporter(t)
+
+	cert, _ := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "not-before", Namespace: "default"},
+		Type:       v1.SecretTypeTLS,
+		Data:       map[string][]byte{"tls.crt": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})},
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(&secretCollector{e: e, secret: secret}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	labels := fmt.Sprintf(`{alias="",certificatename="",name="not-before",namespace="default",secretkey="tls.crt",serialnumber="%x"}`, cert.SerialNumber)
+	expected := fmt.Sprintf("cert_exporter_secret_not_before%s %v", labels, float64(cert.NotBefore.Unix()))
+	if !strings.Contains(body, expected) {
+		t.Fatalf("expected sample %q in\n%s", expected, body)
+	}
+	if !strings.Contains(body, metricName+labels) {
+		t.Fatalf("expected a not after sample with labels %s", labels)
+	}
+}
+
+// TestScrape_CertificateInfo makes sure the info sample of a certificate can
+// be joined to its not after sample through the serialnumber label.
+func TestScrape_CertificateInfo(t *testing.T) {
+	e := newTestExporter(t)
+
+	cert, _ := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "info", Namespace: "default"},
+		Type:       v1.SecretTypeTLS,
+		Data:       map[string][]byte{"tls.crt": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})},
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(&secretCollector{e: e, secret: secret}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	var info []string
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "cert_exporter_secret_certificate_info{") {
+			info = append(info, line)
+		}
+	}
+	if len(info) != 1 {
+		t.Fatalf("expected 1 info sample, got %d", len(info))
+	}
+
+	for _, label := range []string{
+		fmt.Sprintf(`serialnumber="%x"`, cert.SerialNumber),
+		`name="info"`,
+		`secretkey="tls.crt"`,
+		`key_algorithm="ECDSA"`,
+		`signature_algorithm="ECDSA-SHA256"`,
+	} {
+		if !strings.Contains(info[0], label) {
+			t.Fatalf("expected info sample to carry %s, got %s", label, info[0])
+		}
+	}
+}
+
+// TestScrape_Chain covers a leaf chaining to the ca.crt of its secret through
+// an intermediate in tls.crt, and one issued by a CA that is no longer the
+// one in ca.crt.
+func TestScrape_Chain(t *testing.T) {
+	e := newTestExporter(t)
+
+	root, rootKey := certtest.Generate(t, "root", true, time.Now().Add(10*365*24*time.Hour), nil, nil)
+	intermediate, intermediateKey := certtest.Generate(t, "intermediate", true, time.Now().Add(90*24*time.Hour), root, rootKey)
+	leaf, _ := certtest.Generate(t, "leaf", false, time.Now().Add(365*24*time.Hour), intermediate, intermediateKey)
+	rotated, rotatedKey := certtest.Generate(t, "root", true, time.Now().Add(10*365*24*time.Hour), nil, nil)
+	stale, _ := certtest.Generate(t, "stale", false, time.Now().Add(30*24*time.Hour), rotated, rotatedKey)
+
+	encode := func(certs ...*x509.Certificate) []byte {
+		var data []byte
+		for _, cert := range certs {
+			data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+		}
+		return data
+	}
+
+	reg := prometheus.NewRegistry()
+	err := reg.Register(&multiSecretCollector{e: e, secrets: []v1.Secret{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "valid", Namespace: "default"},
+			Type:       v1.SecretTypeTLS,
+			Data:       map[string][]byte{"ca.crt": encode(root), "tls.crt": encode(leaf, intermediate)},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "stale", Namespace: "default"},
+			Type:       v1.SecretTypeTLS,
+			Data:       map[string][]byte{"ca.crt": encode(root), "tls.crt": encode(stale)},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	expected := []string{
+		fmt.Sprintf(`cert_exporter_secret_chain_valid{alias="",certificatename="",name="valid",namespace="default",secretkey="tls.crt",serialnumber="%x"} 1`, leaf.SerialNumber),
+		// The intermediate expires before the leaf, which makes the chain expire.
+		fmt.Sprintf(`cert_exporter_secret_chain_not_after{alias="",certificatename="",name="valid",namespace="default",secretkey="tls.crt",serialnumber="%x"} %v`, leaf.SerialNumber, float64(intermediate.NotAfter.Unix())),
+		fmt.Sprintf(`cert_exporter_secret_chain_valid{alias="",certificatename="",name="stale",namespace="default",secretkey="tls.crt",serialnumber="%x"} 0`, stale.SerialNumber),
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in\n%s", sample, body)
+		}
+	}
+}
+
+func TestScrape_Revoked(t *testing.T) {
+	ca, caKey := certtest.Generate(t, "ca", true, time.Now().Add(365*24*time.Hour), nil, nil)
+	revoked, _ := certtest.Generate(t, "revoked", false, time.Now().Add(24*time.Hour), ca, caKey)
+	good, _ := certtest.Generate(t, "good", false, time.Now().Add(24*time.Hour), ca, caKey)
+	other, _ := certtest.Generate(t, "other", false, time.Now().Add(24*time.Hour), nil, nil)
+
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-1 * time.Hour),
+		NextUpdate: time.Now().Add(24 * time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: revoked.SerialNumber, RevocationTime: time.Now().Add(-1 * time.Hour)},
+		},
+	}, ca, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := fake.NewClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "crls", Namespace: "pki", ResourceVersion: "1"},
+		Data:       map[string][]byte{"ca.crl": crl},
+	})
+	refs, err := parseCRLSecrets([]string{"pki/crls"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := newTestExporter(t)
+	e.k8sClient = client
+	e.crlSecrets = newCRLSecrets(client, refs)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	e.startInformers(stop)
+	waitFor(t, func() bool { return e.crlSecrets[0].informer.Informer().HasSynced() })
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "revoked", Namespace: "default"},
+		Type:       v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"tls.crt": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: revoked.Raw}),
+			"ca.crt":  append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: good.Raw}), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: other.Raw})...),
+		},
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(&secretCollector{crls: e.revocationIndex(), e: e, secret: secret}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	expected := []string{
+		fmt.Sprintf(`cert_exporter_secret_revoked{alias="",certificatename="",name="revoked",namespace="default",secretkey="tls.crt",serialnumber="%x"} 1`, revoked.SerialNumber),
+		fmt.Sprintf(`cert_exporter_secret_revoked{alias="",certificatename="",name="revoked",namespace="default",secretkey="ca.crt",serialnumber="%x"} 0`, good.SerialNumber),
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("expected sample %q in\n%s", sample, body)
+		}
+	}
+
+	// No CRL of its issuer is known, so its revocation status is unknown.
+	if strings.Contains(body, fmt.Sprintf(`cert_exporter_secret_revoked{alias="",certificatename="",name="revoked",namespace="default",secretkey="ca.crt",serialnumber="%x"}`, other.SerialNumber)) {
+		t.Error("expected no revocation status for a certificate of an unknown issuer")
+	}
+
+	// CRL secrets are served from the informer and parsed once, scrapes do
+	// not read them from the API server.
+	e.revocationIndex()
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "get" {
+			t.Errorf("expected no direct reads of secrets, got %s of %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+	if cached := e.crlCache[refs[0]]; cached.resourceVersion != "1" || len(cached.crls) != 1 {
+		t.Errorf("expected the parsed CRL secret to be cached, got %d CRLs at resource version %q", len(cached.crls), cached.resourceVersion)
+	}
+}
+
+func TestParseCRLSecrets(t *testing.T) {
+	refs, err := parseCRLSecrets([]string{"pki/crls", "kube-system/etcd-crl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 2 || refs[1].Namespace != "kube-system" || refs[1].Name != "etcd-crl" {
+		t.Fatalf("unexpected refs %v", refs)
+	}
+
+	for _, value := range []string{"crls", "/crls", "pki/"} {
+		_, err := parseCRLSecrets([]string{value})
+		if !IsInvalidConfig(err) {
+			t.Errorf("expected an invalid config error for %q, got %v", value, err)
+		}
+	}
+}
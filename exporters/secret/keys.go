@@ -0,0 +1,100 @@
+package secret
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	v1 "k8s.io/api/core/v1"
+)
+
+// ParseSecretType parses the key patterns of a secret type, given as
+//
+//	<type>:<glob>,<glob>
+//
+// like Opaque:*.crt,*.pem.
+func ParseSecretType(value string) (string, []string, error) {
+	secretType, patterns, found := strings.Cut(value, ":")
+	if !found || secretType == "" || patterns == "" {
+		return "", nil, microerror.Maskf(invalidConfigError, "secret type %#q must be given as <type>:<glob>,<glob>", value)
+	}
+
+	return secretType, strings.Split(patterns, ","), nil
+}
+
+// validateSecretTypes makes sure every secret type has key patterns and all
+// of them are well formed, so a typo fails at startup instead of silently
+// never matching.
+func validateSecretTypes(secretTypes map[string][]string) error {
+	if len(secretTypes) == 0 {
+		return microerror.Maskf(invalidConfigError, "at least one secret type must be given")
+	}
+
+	for secretType, patterns := range secretTypes {
+		if len(patterns) == 0 {
+			return microerror.Maskf(invalidConfigError, "secret type %#q has no key patterns", secretType)
+		}
+
+		for _, pattern := range patterns {
+			_, err := path.Match(pattern, "")
+			if err != nil {
+				return microerror.Maskf(invalidConfigError, "key pattern %#q of secret type %#q: %s", pattern, secretType, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// certKeysFor returns the keys of the given secret matching the key patterns
+// configured for its type, in lexical order. Keys named without a glob are
+// commonly optional, like the ca.crt of TLS secrets issued by a public CA, so
+// their absence is only logged at debug level.
+func (e *Exporter) certKeysFor(secret v1.Secret) []string {
+	patterns := e.secretTypes[string(secret.Type)]
+
+	var keys []string
+	for key := range secret.Data {
+		if matchesAny(patterns, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, pattern := range patterns {
+		if isLiteral(pattern) && secret.Data[pattern] == nil {
+			e.logger.Log("debug", microerror.Maskf(certNotFoundError, "secret %s/%s contains no key matching '%s'", secret.Namespace, secret.Name, pattern))
+		}
+	}
+
+	return keys
+}
+
+// matchesAny returns whether the given key matches one of the given key
+// patterns.
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		ok, _ := path.Match(pattern, key)
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isLiteral(pattern string) bool {
+	return !strings.ContainsAny(pattern, `*?[\`)
+}
+
+// secretTypesOf returns the configured secret types in lexical order.
+func secretTypesOf(secretTypes map[string][]string) []string {
+	var types []string
+	for secretType := range secretTypes {
+		types = append(types, secretType)
+	}
+	sort.Strings(types)
+
+	return types
+}
@@ -0,0 +1,124 @@
+package secret
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseSecretType(t *testing.T) {
+	testCases := []struct {
+		name             string
+		value            string
+		expectedType     string
+		expectedPatterns []string
+		errorMatcher     func(error) bool
+	}{
+		{
+			name:             "case 0: type with globs",
+			value:            "Opaque:*.crt,*.pem",
+			expectedType:     "Opaque",
+			expectedPatterns: []string{"*.crt", "*.pem"},
+		},
+		{
+			name:             "case 1: type with a slash",
+			value:            "kubernetes.io/tls:tls.crt",
+			expectedType:     "kubernetes.io/tls",
+			expectedPatterns: []string{"tls.crt"},
+		},
+		{
+			name:         "case 2: missing patterns",
+			value:        "Opaque",
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 3: missing type",
+			value:        ":*.crt",
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			secretType, patterns, err := ParseSecretType(tc.value)
+			if tc.errorMatcher != nil {
+				if !tc.errorMatcher(err) {
+					t.Fatalf("expected a matching error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if secretType != tc.expectedType || !reflect.DeepEqual(patterns, tc.expectedPatterns) {
+				t.Errorf("expected %s with %v, got %s with %v", tc.expectedType, tc.expectedPatterns, secretType, patterns)
+			}
+		})
+	}
+}
+
+func TestValidateSecretTypes(t *testing.T) {
+	if err := validateSecretTypes(DefaultConfig().SecretTypes); err != nil {
+		t.Errorf("expected the default secret types to be valid, got %v", err)
+	}
+	if err := validateSecretTypes(map[string][]string{"Opaque": {"[.crt"}}); !IsInvalidConfig(err) {
+		t.Errorf("expected a malformed glob to be invalid, got %v", err)
+	}
+	if err := validateSecretTypes(map[string][]string{}); !IsInvalidConfig(err) {
+		t.Errorf("expected no secret types to be invalid, got %v", err)
+	}
+}
+
+// TestScrape_OpaqueSecretKeyPatterns covers certificates stored in Opaque
+// secrets under keys matched by globs, next to private keys the globs match
+// too.
+func TestScrape_OpaqueSecretKeyPatterns(t *testing.T) {
+	e := newTestExporter(t)
+	e.secretTypes = map[string][]string{
+		string(v1.SecretTypeOpaque): {"*.crt", "*.pem"},
+		string(v1.SecretTypeTLS):    tlsCertKeys,
+	}
+
+	_, key := generateSelfSignedCert(t, time.Now().Add(24*time.Hour))
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "etcd-certs", Namespace: "default"},
+		Type:       v1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"client.crt":     generateSelfSignedCertPEM(t, time.Now().Add(24*time.Hour)),
+			"etcd-ca.pem":    generateSelfSignedCertPEM(t, time.Now().Add(48*time.Hour)),
+			"ca-bundle.crt":  generateSelfSignedCertPEM(t, time.Now().Add(72*time.Hour)),
+			"client-key.pem": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+			"config.yaml":    []byte("endpoints: []"),
+		},
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(&secretCollector{e: e, secret: secret}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	samples := samplesFor(body, "etcd-certs")
+	if len(samples) != 3 {
+		t.Fatalf("expected 3 samples, got %d in\n%s", len(samples), body)
+	}
+	for _, key := range []string{"client.crt", "etcd-ca.pem", "ca-bundle.crt"} {
+		if !strings.Contains(strings.Join(samples, "\n"), `secretkey="`+key+`"`) {
+			t.Errorf("expected a sample for %s in\n%s", key, body)
+		}
+	}
+}
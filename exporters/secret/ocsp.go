@@ -0,0 +1,58 @@
+package secret
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/cert-exporter/pkg/chain"
+)
+
+// exportOCSP reports the OCSP status of the certificates of a secret whose
+// issuer is found in it, as last checked by the OCSP checker. Certificates are
+// only reported on once their responder answered.
+func (e *Exporter) exportOCSP(ch chan<- prometheus.Metric, found []foundCert, secretName, secretNamespace, certName string) {
+	if e.ocsp == nil {
+		return
+	}
+
+	var candidates []*x509.Certificate
+	for _, f := range found {
+		candidates = append(candidates, f.cert)
+	}
+
+	for _, f := range found {
+		issuer := chain.Issuer(f.cert, candidates)
+		if issuer == nil {
+			continue
+		}
+
+		status, ok := e.ocsp.Status(f.cert, issuer)
+		if !ok {
+			continue
+		}
+
+		serialNumber := fmt.Sprintf("%x", f.cert.SerialNumber)
+		ch <- prometheus.MustNewConstMetric(e.ocspStatus, prometheus.GaugeValue, 1, secretName, secretNamespace, f.key, certName, serialNumber, f.alias, status)
+	}
+}
+
+// newOCSPStatusDesc describes the OCSP status of a certificate, given by the
+// status label as good, revoked or unknown.
+func newOCSPStatusDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "secret", "ocsp_status"),
+		"OCSP status of the cert as reported by its responder, always 1.",
+		[]string{
+			"name",
+			"namespace",
+			"secretkey",
+			"certificatename",
+			"serialnumber",
+			"alias",
+			"status",
+		},
+		nil,
+	)
+}
@@ -0,0 +1,107 @@
+package secret
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/ocsp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/cert-exporter/pkg/certtest"
+	"github.com/giantswarm/cert-exporter/pkg/revocation"
+)
+
+func TestScrape_OCSPStatus(t *testing.T) {
+	ca, caKey := certtest.Generate(t, "ca", true, time.Now().Add(365*24*time.Hour), nil, nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req, err := ocsp.ParseRequest(body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		resp, err := ocsp.CreateResponse(ca, ca, ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now().Add(-1 * time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+		}, caKey)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		_, _ = w.Write(resp)
+	}))
+	defer srv.Close()
+
+	_, leafKey := certtest.Generate(t, "unused", false, time.Now().Add(time.Hour), nil, nil)
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		OCSPServer:   []string{srv.URL},
+	}, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger, err := micrologger.New(micrologger.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker, err := revocation.NewChecker(revocation.CheckerConfig{Interval: time.Hour, Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go checker.Run(ctx)
+
+	e := newTestExporter(t)
+	e.ocsp = checker
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ocsp", Namespace: "default"},
+		Type:       v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"ca.crt":  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}),
+			"tls.crt": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		},
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(&secretCollector{e: e, secret: secret}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first scrape tracks the certificate, a later one reports its status.
+	expected := fmt.Sprintf(`cert_exporter_secret_ocsp_status{alias="",certificatename="",name="ocsp",namespace="default",secretkey="tls.crt",serialnumber="%x",status="good"} 1`, 42)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, body := serveMetrics(t, reg)
+		if strings.Contains(body, expected) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected sample %q in\n%s", expected, body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,41 @@
+package secret
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// exportPolicyViolations reports every rule of the configured policy violated
+// by the certificates of a secret. Compliant certificates are not reported.
+func (e *Exporter) exportPolicyViolations(ch chan<- prometheus.Metric, found []foundCert, secretName, secretNamespace, certName string) {
+	if e.policy == nil {
+		return
+	}
+
+	for _, f := range found {
+		serialNumber := fmt.Sprintf("%x", f.cert.SerialNumber)
+		for _, rule := range e.policy.Violations(f.cert) {
+			ch <- prometheus.MustNewConstMetric(e.policyViolation, prometheus.GaugeValue, 1, secretName, secretNamespace, f.key, certName, serialNumber, f.alias, rule)
+		}
+	}
+}
+
+// newPolicyViolationDesc describes a rule of the policy violated by a
+// certificate, given by the rule label.
+func newPolicyViolationDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "secret", "policy_violation"),
+		"Rule of the policy violated by the cert, always 1.",
+		[]string{
+			"name",
+			"namespace",
+			"secretkey",
+			"certificatename",
+			"serialnumber",
+			"alias",
+			"rule",
+		},
+		nil,
+	)
+}
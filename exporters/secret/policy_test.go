@@ -0,0 +1,49 @@
+package secret
+
+import (
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/cert-exporter/pkg/certtest"
+	"github.com/giantswarm/cert-exporter/pkg/policy"
+)
+
+func TestScrape_PolicyViolation(t *testing.T) {
+	ca, caKey := certtest.Generate(t, "ca", true, time.Now().Add(10*365*24*time.Hour), nil, nil)
+	leaf, _ := certtest.Generate(t, "leaf", false, time.Now().Add(2*365*24*time.Hour), ca, caKey)
+
+	p := policy.Default()
+	e := newTestExporter(t)
+	e.policy = &p
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy-tls", Namespace: "default"},
+		Type:       v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"ca.crt":  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}),
+			"tls.crt": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw}),
+		},
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(&secretCollector{e: e, secret: secret}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := serveMetrics(t, reg)
+
+	expected := fmt.Sprintf(`cert_exporter_secret_policy_violation{alias="",certificatename="",name="legacy-tls",namespace="default",rule="validity-period",secretkey="tls.crt",serialnumber="%x"} 1`, leaf.SerialNumber)
+	if !strings.Contains(body, expected) {
+		t.Errorf("expected sample %q in\n%s", expected, body)
+	}
+	if strings.Contains(body, `rule="validity-period",secretkey="ca.crt"`) {
+		t.Errorf("expected the long lived CA not to be reported in\n%s", body)
+	}
+}
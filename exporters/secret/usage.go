@@ -0,0 +1,51 @@
+package secret
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/cert-exporter/pkg/usage"
+)
+
+// roleAnnotation overrides the role implied by the key of a secret for its
+// leaf certificates, e.g. client for a client certificate stored in tls.crt.
+const roleAnnotation = "cert-exporter.giantswarm.io/role"
+
+// exportUsageMismatches reports every usage the certificates of a secret lack
+// for the role implied by their key, ca for ca.crt and server for tls.crt,
+// unless overridden by the role annotation.
+func (e *Exporter) exportUsageMismatches(ch chan<- prometheus.Metric, found []foundCert, secretName, secretNamespace, certName, roleOverride string) {
+	for _, f := range found {
+		locationRole := usage.RoleOf(f.key)
+		if roleOverride != "" {
+			locationRole = roleOverride
+		}
+
+		role := usage.RoleFor(f.cert, locationRole)
+		serialNumber := fmt.Sprintf("%x", f.cert.SerialNumber)
+		for _, missing := range usage.Missing(f.cert, role) {
+			ch <- prometheus.MustNewConstMetric(e.usageMismatch, prometheus.GaugeValue, 1, secretName, secretNamespace, f.key, certName, serialNumber, f.alias, role, missing)
+		}
+	}
+}
+
+// newUsageMismatchDesc describes a usage a certificate lacks for its role,
+// given by the usage label.
+func newUsageMismatchDesc() *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName("cert_exporter", "secret", "usage_mismatch"),
+		"Usage the cert lacks for the role implied by its secret key, always 1.",
+		[]string{
+			"name",
+			"namespace",
+			"secretkey",
+			"certificatename",
+			"serialnumber",
+			"alias",
+			"role",
+			"usage",
+		},
+		nil,
+	)
+}
@@ -0,0 +1,86 @@
+package secret
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func generateClientCertPEM(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestScrape_UsageMismatch(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    bool
+	}{
+		{
+			name:     "case 0: client certificate in tls.crt lacks serverAuth",
+			expected: true,
+		},
+		{
+			name:        "case 1: client certificate annotated as client",
+			annotations: map[string]string{roleAnnotation: "client"},
+			expected:    false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newTestExporter(t)
+
+			secret := v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "client-tls", Namespace: "default", Annotations: tc.annotations},
+				Type:       v1.SecretTypeTLS,
+				Data:       map[string][]byte{"tls.crt": generateClientCertPEM(t)},
+			}
+
+			reg := prometheus.NewRegistry()
+			if err := reg.Register(&secretCollector{e: e, secret: secret}); err != nil {
+				t.Fatal(err)
+			}
+
+			_, body := serveMetrics(t, reg)
+
+			sample := `cert_exporter_secret_usage_mismatch{alias="",certificatename="",name="client-tls",namespace="default",role="server",secretkey="tls.crt",serialnumber="1",usage="serverAuth"} 1`
+			if actual := strings.Contains(body, sample); actual != tc.expected {
+				t.Errorf("expected sample %q to be served %t in\n%s", sample, tc.expected, body)
+			}
+			if !tc.expected && strings.Contains(body, "cert_exporter_secret_usage_mismatch{") {
+				t.Errorf("expected no usage mismatch in\n%s", body)
+			}
+		})
+	}
+}
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
@@ -16,9 +19,52 @@ import (
 	"github.com/giantswarm/cert-exporter/exporters/cr"
 	"github.com/giantswarm/cert-exporter/exporters/secret"
 	"github.com/giantswarm/cert-exporter/exporters/token"
+	"github.com/giantswarm/cert-exporter/pkg/policy"
 	"github.com/giantswarm/cert-exporter/pkg/project"
+	"github.com/giantswarm/cert-exporter/pkg/revocation"
 )
 
+// stringsFlag collects the values of a flag given multiple times.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// splitList splits a comma separated flag value, returning nil for an empty one.
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, ",")
+}
+
+// readPasswordsFile reads the passwords held by the given file, one per line,
+// so they can be mounted from a secret instead of showing in the pod spec and
+// may contain commas. Empty lines are skipped.
+func readPasswordsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var passwords []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line != "" {
+			passwords = append(passwords, line)
+		}
+	}
+
+	return passwords, nil
+}
+
 // metricsHandler serves /metrics with ContinueOnError so that a single
 // problematic metric (e.g. a duplicate series) cannot fail the whole scrape and
 // blank out all other metrics.
@@ -40,23 +86,77 @@ func main() {
 		return
 	}
 	var address string
+	var certExclude string
+	var certificateFieldSelector string
+	var certificateLabelSelector string
+	var certInclude string
+	var certMaxDepth int
+	var certMaxFileSize int64
+	var certPathFilters stringsFlag
 	var certPaths string
+	var crlSecrets string
+	var excludedNamespaces string
+	var namespaceLabelSelector string
 	var namespaces string
+	var ocspInterval time.Duration
+	var ocspResponderURL string
+	var pkcs12Passwords string
+	var pkcs12PasswordsFile string
+	var policyMaxValidity time.Duration
+	var policyMinRSAKeySize int
+	var secretDiscoveryMaxKeySize int
+	var secretFieldSelector string
+	var secretLabelSelector string
+	var secretTypes stringsFlag
 	var tokenPath string
+	var trustStoreExpiryWindow time.Duration
+	var trustStorePaths string
 	var vaultURL string
 	var help bool
 	var monitorCertificates bool
 	var monitorFiles bool
 	var monitorSecrets bool
+	var ocspEnabled bool
+	var policyEnabled bool
+	var policyWeakSignatures bool
+	var secretDiscovery bool
+	var watchFiles bool
 	flag.StringVar(&address, "address", ":9005", "address which cert-exporter uses to listen and serve")
+	flag.StringVar(&certificateFieldSelector, "certificate-field-selector", "", "field selector restricting the cert-manager certificates monitored, e.g. metadata.name!=test")
+	flag.StringVar(&certificateLabelSelector, "certificate-label-selector", "", "label selector restricting the cert-manager certificates monitored, e.g. team=platform")
 	flag.StringVar(&certPaths, "cert-paths", "", "comma separated folders containing certs to export")
-	flag.StringVar(&namespaces, "namespaces", "", "comma separated namespaces in which to monitor TLS secrets")
+	flag.StringVar(&certInclude, "cert-include", "", "comma separated globs of the files to read below --cert-paths, matched against the file name or, if containing a slash, the relative path")
+	flag.StringVar(&certExclude, "cert-exclude", "", "comma separated globs of the files and folders to skip below --cert-paths")
+	flag.IntVar(&certMaxDepth, "cert-max-depth", 0, "number of folder levels to walk below --cert-paths, 0 for unlimited")
+	flag.Int64Var(&certMaxFileSize, "cert-max-file-size", 0, "size in bytes above which files below --cert-paths are skipped, 0 for unlimited")
+	flag.Var(&certPathFilters, "cert-path-filter", "filter overriding the --cert-* filter flags for one cert path, as <path>:include=<globs>;exclude=<globs>;max-depth=<n>;max-file-size=<bytes> (can be repeated)")
+	flag.StringVar(&crlSecrets, "crl-secrets", "", "comma separated secrets holding CRLs to check the certificates of TLS secrets against, as <namespace>/<name>")
+	flag.StringVar(&excludedNamespaces, "excluded-namespaces", "", "comma separated namespaces in which never to monitor secrets and cert-manager certificates, e.g. kube-system")
+	flag.StringVar(&namespaceLabelSelector, "namespace-label-selector", "", "label selector restricting the namespaces in which to monitor secrets and cert-manager certificates, watched to pick up new namespaces, e.g. tenant=true")
+	flag.StringVar(&namespaces, "namespaces", "", "comma separated namespaces in which to monitor secrets and cert-manager certificates")
+	flag.DurationVar(&ocspInterval, "ocsp-interval", time.Hour, "how often to check the OCSP status of certificates whose last response has no next update")
+	flag.StringVar(&ocspResponderURL, "ocsp-responder-url", "", "URL of the OCSP responder to query instead of the one of each certificate")
+	flag.DurationVar(&policyMaxValidity, "policy-max-validity", policy.Default().MaxValidity, "longest validity period of leaf certificates allowed by --policy, 0 to disable the rule")
+	flag.IntVar(&policyMinRSAKeySize, "policy-min-rsa-key-size", policy.Default().MinRSAKeySize, "smallest RSA key size in bits allowed by --policy, 0 to disable the rule")
+	flag.StringVar(&pkcs12Passwords, "pkcs12-passwords", "", "comma separated passwords to try when opening PKCS#12 keystores, visible in the process arguments, prefer --pkcs12-passwords-file")
+	flag.StringVar(&pkcs12PasswordsFile, "pkcs12-passwords-file", "", "file holding passwords to try when opening PKCS#12 keystores, one per line, e.g. mounted from a secret")
+	flag.DurationVar(&trustStoreExpiryWindow, "trust-store-expiry-window", cert.DefaultConfig().TrustStoreExpiryWindow, "how far ahead certificates of --trust-store-paths are counted as expiring")
+	flag.StringVar(&trustStorePaths, "trust-store-paths", "", "comma separated trust store folders, e.g. /etc/ssl/certs, whose certs are deduplicated and summarised instead of exported one by one")
+	flag.IntVar(&secretDiscoveryMaxKeySize, "secret-discovery-max-key-size", secret.DefaultConfig().DiscoveryMaxKeySize, "size in bytes above which secret keys are not inspected by --secret-discovery, 0 for unlimited")
+	flag.StringVar(&secretFieldSelector, "secret-field-selector", "", "field selector restricting the secrets monitored, e.g. metadata.name!=test")
+	flag.StringVar(&secretLabelSelector, "secret-label-selector", "", "label selector restricting the secrets monitored, e.g. team=platform")
+	flag.Var(&secretTypes, "secret-type", "type of the secrets to monitor along with the globs of their keys holding certificates, as <type>:<glob>,<glob>, e.g. Opaque:*.crt,*.pem (can be repeated, defaults to kubernetes.io/tls:ca.crt,tls.crt)")
 	flag.StringVar(&tokenPath, "token-path", "", "folder containing Vault tokens to export")
 	flag.StringVar(&vaultURL, "vault-url", "", "URL of Vault server")
 	flag.BoolVar(&help, "help", false, "print usage and exit")
 	flag.BoolVar(&monitorCertificates, "monitor-certificates", true, "monitor expiry of cert-manager certificates")
 	flag.BoolVar(&monitorFiles, "monitor-files", true, "monitor expiry certificate files")
-	flag.BoolVar(&monitorSecrets, "monitor-secrets", true, "monitor expiry of Kubernetes TLS Secrets (type kubernetes.io/tls)")
+	flag.BoolVar(&monitorSecrets, "monitor-secrets", true, "monitor expiry of Kubernetes Secrets, of type kubernetes.io/tls unless --secret-type is given")
+	flag.BoolVar(&ocspEnabled, "ocsp", false, "check the OCSP status of certificates carrying an OCSP URL whose issuer is found next to them")
+	flag.BoolVar(&policyEnabled, "policy", false, "audit certificates against the policy given by the --policy-* flags and export the rules they violate")
+	flag.BoolVar(&policyWeakSignatures, "policy-weak-signatures", policy.Default().WeakSignatures, "forbid signatures using SHA-1 or weaker digests in --policy")
+	flag.BoolVar(&secretDiscovery, "secret-discovery", false, "inspect every key of every secret in --namespaces, whatever its type, for PEM certificates")
+	flag.BoolVar(&watchFiles, "watch-files", false, "watch --cert-paths with inotify and only walk them again after they changed")
 	flag.Parse()
 
 	if help {
@@ -68,12 +168,67 @@ func main() {
 		panic(microerror.Maskf(invalidConfigError, "all exporters are disabled"))
 	}
 
+	passwords := splitList(pkcs12Passwords)
+	if pkcs12PasswordsFile != "" {
+		filePasswords, err := readPasswordsFile(pkcs12PasswordsFile)
+		if err != nil {
+			panic(microerror.Mask(err))
+		}
+		passwords = append(passwords, filePasswords...)
+	}
+
+	var ocspChecker *revocation.Checker
+	if ocspEnabled {
+		logger, err := micrologger.New(micrologger.Config{})
+		if err != nil {
+			panic(microerror.Mask(err))
+		}
+
+		ocspChecker, err = revocation.NewChecker(revocation.CheckerConfig{
+			Interval:     ocspInterval,
+			Logger:       logger,
+			ResponderURL: ocspResponderURL,
+		})
+		if err != nil {
+			panic(microerror.Mask(err))
+		}
+		go ocspChecker.Run(context.Background())
+	}
+
+	var certPolicy *policy.Policy
+	if policyEnabled {
+		certPolicy = &policy.Policy{
+			MaxValidity:    policyMaxValidity,
+			MinRSAKeySize:  policyMinRSAKeySize,
+			WeakSignatures: policyWeakSignatures,
+		}
+	}
+
 	if monitorFiles {
-		if certPaths == "" {
+		if certPaths == "" && trustStorePaths == "" {
 			panic(microerror.Maskf(invalidConfigError, "path to cert folder can not be empty"))
 		}
 		c := cert.DefaultConfig()
-		c.Paths = strings.Split(certPaths, ",")
+		c.Paths = splitList(certPaths)
+		c.Filter = cert.Filter{
+			Include:     splitList(certInclude),
+			Exclude:     splitList(certExclude),
+			MaxDepth:    certMaxDepth,
+			MaxFileSize: certMaxFileSize,
+		}
+		for _, value := range certPathFilters {
+			path, filter, err := cert.ParsePathFilter(value, c.Filter)
+			if err != nil {
+				panic(microerror.Mask(err))
+			}
+			c.PathFilters[path] = filter
+		}
+		c.PKCS12Passwords = passwords
+		c.OCSPChecker = ocspChecker
+		c.Policy = certPolicy
+		c.TrustStoreExpiryWindow = trustStoreExpiryWindow
+		c.TrustStorePaths = splitList(trustStorePaths)
+		c.Watch = watchFiles
 
 		certExporter, err := cert.New(c)
 		if err != nil {
@@ -88,6 +243,26 @@ func main() {
 		if namespaces != "" {
 			c.Namespaces = strings.Split(namespaces, ",")
 		}
+		c.ExcludedNamespaces = splitList(excludedNamespaces)
+		c.NamespaceLabelSelector = namespaceLabelSelector
+		c.PKCS12Passwords = passwords
+		c.CRLSecrets = splitList(crlSecrets)
+		if len(secretTypes) > 0 {
+			c.SecretTypes = map[string][]string{}
+			for _, value := range secretTypes {
+				secretType, patterns, err := secret.ParseSecretType(value)
+				if err != nil {
+					panic(microerror.Mask(err))
+				}
+				c.SecretTypes[secretType] = patterns
+			}
+		}
+		c.Discovery = secretDiscovery
+		c.DiscoveryMaxKeySize = secretDiscoveryMaxKeySize
+		c.FieldSelector = secretFieldSelector
+		c.LabelSelector = secretLabelSelector
+		c.OCSPChecker = ocspChecker
+		c.Policy = certPolicy
 
 		secretExporter, err := secret.New(c)
 		if err != nil {
@@ -115,6 +290,10 @@ func main() {
 		if namespaces != "" {
 			c.Namespaces = strings.Split(namespaces, ",")
 		}
+		c.ExcludedNamespaces = splitList(excludedNamespaces)
+		c.NamespaceLabelSelector = namespaceLabelSelector
+		c.FieldSelector = certificateFieldSelector
+		c.LabelSelector = certificateLabelSelector
 
 		crExporter, err := cr.New(c)
 		if err != nil {
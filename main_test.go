@@ -4,6 +4,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -65,3 +68,28 @@ func TestMetricsHandler_DuplicateSeriesDoesNotFailScrape(t *testing.T) {
 		t.Fatal("expected unrelated metrics to still be served when another collector produces a duplicate series")
 	}
 }
+
+// TestReadPasswordsFile covers passwords holding commas, which cannot be given
+// with --pkcs12-passwords, and files written with CRLF line endings.
+func TestReadPasswordsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwords")
+	err := os.WriteFile(path, []byte("changeit\r\n\nsecret,with,commas\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passwords, err := readPasswordsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"changeit", "secret,with,commas"}
+	if !reflect.DeepEqual(passwords, expected) {
+		t.Fatalf("expected %q, got %q", expected, passwords)
+	}
+
+	_, err = readPasswordsFile(filepath.Join(t.TempDir(), "missing"))
+	if err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,85 @@
+// Package certinfo describes a certificate through the labels of the
+// certificate info metrics, so on-call can tell what a certificate is.
+package certinfo
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxSANs is the number of subject alternative names put into the sans label.
+// Certificates with more, e.g. wildcard-less ingress certificates, would
+// otherwise produce arbitrarily long label values.
+const maxSANs = 10
+
+// LabelNames are the names of the labels returned by Labels, in order.
+var LabelNames = []string{
+	"subject_cn",
+	"issuer_cn",
+	"fingerprint_sha256",
+	"key_algorithm",
+	"key_size",
+	"signature_algorithm",
+	"is_ca",
+	"sans",
+}
+
+// Labels returns the label values describing the given certificate, in the
+// order of LabelNames.
+func Labels(cert *x509.Certificate) []string {
+	return []string{
+		cert.Subject.CommonName,
+		cert.Issuer.CommonName,
+		Fingerprint(cert),
+		cert.PublicKeyAlgorithm.String(),
+		keySize(cert),
+		cert.SignatureAlgorithm.String(),
+		strconv.FormatBool(cert.IsCA),
+		sans(cert),
+	}
+}
+
+// Fingerprint returns the hex encoded SHA-256 digest of the DER encoded
+// certificate, as shown by `openssl x509 -fingerprint -sha256`.
+func Fingerprint(cert *x509.Certificate) string {
+	return fmt.Sprintf("%x", sha256.Sum256(cert.Raw))
+}
+
+func keySize(cert *x509.Certificate) string {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return strconv.Itoa(key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return strconv.Itoa(key.Curve.Params().BitSize)
+	case ed25519.PublicKey:
+		return strconv.Itoa(len(key) * 8)
+	default:
+		return ""
+	}
+}
+
+// sans returns the comma separated subject alternative names of the given
+// certificate, truncated to maxSANs entries followed by the number left out.
+func sans(cert *x509.Certificate) string {
+	var names []string
+	names = append(names, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+
+	if len(names) > maxSANs {
+		return fmt.Sprintf("%s,+%d more", strings.Join(names[:maxSANs], ","), len(names)-maxSANs)
+	}
+
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,106 @@
+package certinfo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestLabels(t *testing.T) {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root-ca"},
+		NotBefore:             time.Now().Add(-1 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "api.example.com"},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		DNSNames:     []string{"api.example.com", "api"},
+		IPAddresses:  []net.IP{net.ParseIP("10.0.0.1")},
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		cert     *x509.Certificate
+		expected []string
+	}{
+		{
+			name:     "CA",
+			cert:     ca,
+			expected: []string{"root-ca", "root-ca", Fingerprint(ca), "RSA", "2048", "SHA256-RSA", "true", ""},
+		},
+		{
+			name:     "leaf",
+			cert:     leaf,
+			expected: []string{"api.example.com", "root-ca", Fingerprint(leaf), "ECDSA", "384", "SHA256-RSA", "false", "api.example.com,api,10.0.0.1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			labels := Labels(tc.cert)
+			if len(labels) != len(LabelNames) {
+				t.Fatalf("expected %d label values, got %d", len(LabelNames), len(labels))
+			}
+			for i, name := range LabelNames {
+				if labels[i] != tc.expected[i] {
+					t.Fatalf("expected %s to be %q, got %q", name, tc.expected[i], labels[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSANs_Truncated(t *testing.T) {
+	var names []string
+	for i := 0; i < maxSANs+3; i++ {
+		names = append(names, fmt.Sprintf("host-%d.example.com", i))
+	}
+
+	got := sans(&x509.Certificate{DNSNames: names})
+
+	expected := "host-0.example.com,host-1.example.com,host-2.example.com,host-3.example.com,host-4.example.com,host-5.example.com,host-6.example.com,host-7.example.com,host-8.example.com,host-9.example.com,+3 more"
+	if got != expected {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+}
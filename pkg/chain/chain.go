@@ -0,0 +1,116 @@
+// Package chain builds and verifies the chain of a leaf certificate against
+// the CA certificates found next to it, like the CA bundle in the same folder
+// or the ca.crt of the same secret.
+package chain
+
+import (
+	"bytes"
+	"crypto/x509"
+	"time"
+)
+
+// Result is the chain built for a leaf certificate.
+type Result struct {
+	// Chain is the leaf followed by its issuers found among the candidates,
+	// up to the topmost one, which is trusted as the root.
+	Chain []*x509.Certificate
+	// Err tells why the chain does not verify, nil if it does.
+	Err error
+}
+
+// Valid returns true if the chain verifies.
+func (r Result) Valid() bool {
+	return r.Err == nil
+}
+
+// NotAfter returns the earliest expiry of any certificate in the chain, which
+// is when the leaf stops being usable.
+func (r Result) NotAfter() time.Time {
+	var notAfter time.Time
+	for _, cert := range r.Chain {
+		if notAfter.IsZero() || cert.NotAfter.Before(notAfter) {
+			notAfter = cert.NotAfter
+		}
+	}
+
+	return notAfter
+}
+
+// IsLeaf returns true if the given certificate is an end entity certificate
+// rather than a CA.
+func IsLeaf(cert *x509.Certificate) bool {
+	return !cert.IsCA
+}
+
+// Verify builds the chain of the given leaf from the candidates and verifies
+// it at the given time. It returns false if the issuer of the leaf is not among
+// the candidates, in which case there is no chain to report on.
+func Verify(leaf *x509.Certificate, candidates []*x509.Certificate, now time.Time) (Result, bool) {
+	chain := []*x509.Certificate{leaf}
+	for cert := leaf; !isSelfSigned(cert); {
+		issuer := Issuer(cert, candidates)
+		if issuer == nil || contains(chain, issuer) {
+			break
+		}
+
+		chain = append(chain, issuer)
+		cert = issuer
+	}
+
+	if len(chain) == 1 {
+		return Result{}, false
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(chain[len(chain)-1])
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1 : len(chain)-1] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{
+		CurrentTime:   now,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		Roots:         roots,
+	})
+
+	return Result{Chain: chain, Err: err}, true
+}
+
+// Issuer returns the CA among the candidates that issued the given
+// certificate, or nil if there is none. CAs named like the issuer whose key
+// signed the certificate are preferred, so a rotated CA keeping its name is
+// told apart from the one in use. A CA only matching by name is returned
+// otherwise, for the chain to fail to verify.
+func Issuer(cert *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	var named *x509.Certificate
+	for _, candidate := range candidates {
+		if !candidate.IsCA || candidate.Equal(cert) || !bytes.Equal(candidate.RawSubject, cert.RawIssuer) {
+			continue
+		}
+
+		if cert.CheckSignatureFrom(candidate) == nil {
+			return candidate
+		}
+		if named == nil {
+			named = candidate
+		}
+	}
+
+	return named
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(cert) == nil
+}
+
+func contains(certs []*x509.Certificate, cert *x509.Certificate) bool {
+	for _, c := range certs {
+		if c.Equal(cert) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package chain
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/cert-exporter/pkg/certtest"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+
+	root, rootKey := certtest.Generate(t, "root", true, now.Add(10*365*24*time.Hour), nil, nil)
+	intermediate, intermediateKey := certtest.Generate(t, "intermediate", true, now.Add(365*24*time.Hour), root, rootKey)
+	leaf, _ := certtest.Generate(t, "leaf", false, now.Add(30*24*time.Hour), intermediate, intermediateKey)
+	rotatedRoot, _ := certtest.Generate(t, "root", true, now.Add(10*365*24*time.Hour), nil, nil)
+	expiredIntermediate, expiredIntermediateKey := certtest.Generate(t, "expired", true, now.Add(-1*time.Minute), root, rootKey)
+	orphan, _ := certtest.Generate(t, "orphan", false, now.Add(24*time.Hour), expiredIntermediate, expiredIntermediateKey)
+
+	testCases := []struct {
+		name       string
+		leaf       *x509.Certificate
+		candidates []*x509.Certificate
+		found      bool
+		valid      bool
+		chain      int
+		notAfter   time.Time
+	}{
+		{
+			name:       "case 0: chain up to the root verifies",
+			leaf:       leaf,
+			candidates: []*x509.Certificate{leaf, intermediate, root},
+			found:      true,
+			valid:      true,
+			chain:      3,
+			notAfter:   leaf.NotAfter,
+		},
+		{
+			name:       "case 1: chain up to the intermediate verifies",
+			leaf:       leaf,
+			candidates: []*x509.Certificate{intermediate},
+			found:      true,
+			valid:      true,
+			chain:      2,
+			notAfter:   leaf.NotAfter,
+		},
+		{
+			name:       "case 2: intermediate signed by a rotated root of the same name does not verify",
+			leaf:       leaf,
+			candidates: []*x509.Certificate{intermediate, rotatedRoot},
+			found:      true,
+			valid:      false,
+			chain:      3,
+			notAfter:   leaf.NotAfter,
+		},
+		{
+			name:       "case 3: expired intermediate does not verify and expires the chain",
+			leaf:       orphan,
+			candidates: []*x509.Certificate{expiredIntermediate, root},
+			found:      true,
+			valid:      false,
+			chain:      3,
+			notAfter:   expiredIntermediate.NotAfter,
+		},
+		{
+			name:       "case 4: issuer not among the candidates",
+			leaf:       leaf,
+			candidates: []*x509.Certificate{root},
+			found:      false,
+		},
+		{
+			name:       "case 5: self-signed root has no chain",
+			leaf:       root,
+			candidates: []*x509.Certificate{root, rotatedRoot},
+			found:      false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, found := Verify(tc.leaf, tc.candidates, now)
+			if found != tc.found {
+				t.Fatalf("expected found %t, got %t", tc.found, found)
+			}
+			if !found {
+				return
+			}
+
+			if result.Valid() != tc.valid {
+				t.Errorf("expected valid %t, got %t (%v)", tc.valid, result.Valid(), result.Err)
+			}
+			if len(result.Chain) != tc.chain {
+				t.Errorf("expected a chain of %d, got %d", tc.chain, len(result.Chain))
+			}
+			if !result.NotAfter().Equal(tc.notAfter) {
+				t.Errorf("expected the chain to expire at %s, got %s", tc.notAfter, result.NotAfter())
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package keystore
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var incorrectPasswordError = &microerror.Error{
+	Kind: "incorrectPasswordError",
+}
+
+// IsIncorrectPassword asserts incorrectPasswordError.
+func IsIncorrectPassword(err error) bool {
+	return microerror.Cause(err) == incorrectPasswordError
+}
+
+var invalidKeystoreError = &microerror.Error{
+	Kind: "invalidKeystoreError",
+}
+
+// IsInvalidKeystore asserts invalidKeystoreError.
+func IsInvalidKeystore(err error) bool {
+	return microerror.Cause(err) == invalidKeystoreError
+}
+
+var unsupportedEntryError = &microerror.Error{
+	Kind: "unsupportedEntryError",
+}
+
+// IsUnsupportedEntry asserts unsupportedEntryError.
+func IsUnsupportedEntry(err error) bool {
+	return microerror.Cause(err) == unsupportedEntryError
+}
@@ -0,0 +1,233 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	jksMagic   = 0xfeedfeed
+	jceksMagic = 0xcececece
+
+	jksPrivateKeyEntry  = 1
+	jksTrustedCertEntry = 2
+	jceksSecretKeyEntry = 3
+)
+
+// Entry is a certificate held by a Java KeyStore along with the alias it is
+// stored under. Every certificate of a private key entry's chain shares the
+// alias of that entry.
+type Entry struct {
+	Alias       string
+	Certificate *x509.Certificate
+}
+
+// IsJKS returns true if the given data starts with the magic number of a Java
+// KeyStore (JKS) or a Java Cryptography Extension KeyStore (JCEKS).
+func IsJKS(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+
+	magic := binary.BigEndian.Uint32(data)
+
+	return magic == jksMagic || magic == jceksMagic
+}
+
+// DecodeJKS returns every certificate held by a JKS or JCEKS keystore.
+// Certificates are stored unencrypted, so unlike private keys they can be read
+// without the store password and the integrity digest is not verified.
+//
+// JCEKS secret key entries are serialized Java objects whose length cannot be
+// known without deserializing them. Reading stops at the first one, and the
+// entries found up to that point are returned along with an
+// unsupportedEntryError.
+func DecodeJKS(data []byte) ([]Entry, error) {
+	r := bytes.NewReader(data)
+
+	var header struct {
+		Magic   uint32
+		Version uint32
+		Count   uint32
+	}
+	err := binary.Read(r, binary.BigEndian, &header)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if header.Magic != jksMagic && header.Magic != jceksMagic {
+		return nil, microerror.Maskf(invalidKeystoreError, "unknown magic number %#x", header.Magic)
+	}
+	if header.Version != 1 && header.Version != 2 {
+		return nil, microerror.Maskf(invalidKeystoreError, "unsupported version %d", header.Version)
+	}
+
+	var entries []Entry
+	for i := uint32(0); i < header.Count; i++ {
+		var tag uint32
+		err = binary.Read(r, binary.BigEndian, &tag)
+		if err != nil {
+			return entries, microerror.Mask(err)
+		}
+
+		alias, err := readJKSString(r)
+		if err != nil {
+			return entries, microerror.Mask(err)
+		}
+
+		// Skip the creation timestamp.
+		_, err = r.Seek(8, io.SeekCurrent)
+		if err != nil {
+			return entries, microerror.Mask(err)
+		}
+
+		switch tag {
+		case jksPrivateKeyEntry:
+			err = skipJKSBytes(r)
+			if err != nil {
+				return entries, microerror.Mask(err)
+			}
+
+			var chainLen uint32
+			err = binary.Read(r, binary.BigEndian, &chainLen)
+			if err != nil {
+				return entries, microerror.Mask(err)
+			}
+
+			for j := uint32(0); j < chainLen; j++ {
+				cert, err := readJKSCertificate(r, header.Version)
+				if err != nil {
+					return entries, microerror.Mask(err)
+				}
+
+				entries = append(entries, Entry{Alias: alias, Certificate: cert})
+			}
+
+		case jksTrustedCertEntry:
+			cert, err := readJKSCertificate(r, header.Version)
+			if err != nil {
+				return entries, microerror.Mask(err)
+			}
+
+			entries = append(entries, Entry{Alias: alias, Certificate: cert})
+
+		case jceksSecretKeyEntry:
+			return entries, microerror.Maskf(unsupportedEntryError, "secret key entry %#q stops reading the keystore", alias)
+
+		default:
+			return entries, microerror.Maskf(invalidKeystoreError, "unknown entry tag %d for alias %#q", tag, alias)
+		}
+	}
+
+	return entries, nil
+}
+
+// readJKSString reads a length prefixed modified UTF-8 string, as written by
+// Java's DataOutputStream.writeUTF.
+func readJKSString(r *bytes.Reader) (string, error) {
+	var length uint16
+	err := binary.Read(r, binary.BigEndian, &length)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	b := make([]byte, length)
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return decodeModifiedUTF8(b), nil
+}
+
+// decodeModifiedUTF8 decodes the modified UTF-8 Java writes strings in, where
+// NUL is encoded on two bytes and supplementary characters as the UTF-8 of
+// both halves of their UTF-16 surrogate pair. Malformed bytes are replaced
+// with U+FFFD, so aliases are always valid UTF-8.
+func decodeModifiedUTF8(b []byte) string {
+	units := make([]uint16, 0, len(b))
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case c < 0x80:
+			units = append(units, uint16(c))
+			i++
+		case c&0xe0 == 0xc0 && i+1 < len(b) && b[i+1]&0xc0 == 0x80:
+			units = append(units, uint16(c&0x1f)<<6|uint16(b[i+1]&0x3f))
+			i += 2
+		case c&0xf0 == 0xe0 && i+2 < len(b) && b[i+1]&0xc0 == 0x80 && b[i+2]&0xc0 == 0x80:
+			units = append(units, uint16(c&0x0f)<<12|uint16(b[i+1]&0x3f)<<6|uint16(b[i+2]&0x3f))
+			i += 3
+		default:
+			units = append(units, utf8.RuneError)
+			i++
+		}
+	}
+
+	return string(utf16.Decode(units))
+}
+
+func readJKSBytes(r *bytes.Reader) ([]byte, error) {
+	var length uint32
+	err := binary.Read(r, binary.BigEndian, &length)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if int64(length) > int64(r.Len()) {
+		return nil, microerror.Maskf(invalidKeystoreError, "entry of %d bytes exceeds the keystore", length)
+	}
+
+	b := make([]byte, length)
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return b, nil
+}
+
+func skipJKSBytes(r *bytes.Reader) error {
+	var length uint32
+	err := binary.Read(r, binary.BigEndian, &length)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = r.Seek(int64(length), io.SeekCurrent)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// readJKSCertificate reads a certificate, which version 2 keystores prefix
+// with its type. Only X.509 certificates are supported.
+func readJKSCertificate(r *bytes.Reader, version uint32) (*x509.Certificate, error) {
+	if version == 2 {
+		certType, err := readJKSString(r)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		if certType != "X.509" {
+			return nil, microerror.Maskf(unsupportedEntryError, "certificate type %#q", certType)
+		}
+	}
+
+	der, err := readJKSBytes(r)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return cert, nil
+}
@@ -0,0 +1,117 @@
+package keystore
+
+import (
+	"crypto/x509"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/giantswarm/cert-exporter/pkg/keystore/keystoretest"
+)
+
+func TestDecodeJKS(t *testing.T) {
+	leaf, _ := generateSelfSignedCert(t, 1)
+	ca, _ := generateSelfSignedCert(t, 2)
+
+	data := keystoretest.EncodeJKS(t, keystoretest.JKSMagic, []keystoretest.JKSEntry{
+		{Alias: "kafka", Tag: keystoretest.JKSPrivateKeyEntry, Chain: []*x509.Certificate{leaf, ca}},
+		{Alias: "root", Tag: keystoretest.JKSTrustedCertEntry, Chain: []*x509.Certificate{ca}},
+	})
+
+	if !IsJKS(data) {
+		t.Fatal("expected keystore to be detected as JKS")
+	}
+
+	entries, err := DecodeJKS(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"kafka", "kafka", "root"}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, alias := range expected {
+		if entries[i].Alias != alias {
+			t.Fatalf("expected entry %d to have alias %q, got %q", i, alias, entries[i].Alias)
+		}
+	}
+	if entries[1].Certificate.SerialNumber.Int64() != 2 {
+		t.Fatal("expected the second entry to be the CA of the chain")
+	}
+}
+
+func TestDecodeJKS_JCEKSSecretKey(t *testing.T) {
+	ca, _ := generateSelfSignedCert(t, 1)
+
+	data := keystoretest.EncodeJKS(t, keystoretest.JCEKSMagic, []keystoretest.JKSEntry{
+		{Alias: "root", Tag: keystoretest.JKSTrustedCertEntry, Chain: []*x509.Certificate{ca}},
+		{Alias: "aes", Tag: keystoretest.JCEKSSecretKeyEntry},
+	})
+
+	entries, err := DecodeJKS(data)
+	if !IsUnsupportedEntry(err) {
+		t.Fatalf("expected unsupported entry error, got %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected the entry before the secret key to be returned, got %d entries", len(entries))
+	}
+}
+
+func TestIsJKS_Certificate(t *testing.T) {
+	cert, _ := generateSelfSignedCert(t, 1)
+
+	if IsJKS(cert.Raw) {
+		t.Fatal("expected a DER certificate not to be detected as JKS")
+	}
+}
+
+func TestDecodeModifiedUTF8(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		{
+			name:     "case 0: ASCII",
+			input:    []byte("kafka"),
+			expected: "kafka",
+		},
+		{
+			name:     "case 1: NUL on two bytes",
+			input:    []byte("a\xc0\x80b"),
+			expected: "a\x00b",
+		},
+		{
+			name:     "case 2: two byte character",
+			input:    []byte("caf\xc3\xa9"),
+			expected: "café",
+		},
+		{
+			name:     "case 3: supplementary character as a surrogate pair",
+			input:    []byte("\xed\xa0\xbd\xed\xb8\x80"),
+			expected: "😀",
+		},
+		{
+			name:     "case 4: malformed bytes",
+			input:    []byte("bad\xff"),
+			expected: "bad�",
+		},
+		{
+			name:     "case 5: unpaired surrogate",
+			input:    []byte("\xed\xa0\xbd"),
+			expected: "�",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := decodeModifiedUTF8(tc.input)
+			if got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("expected valid UTF-8, got %q", got)
+			}
+		})
+	}
+}
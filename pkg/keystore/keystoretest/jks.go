@@ -0,0 +1,98 @@
+package keystoretest
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/binary"
+	"sort"
+	"testing"
+)
+
+// Magic numbers and entry tags of Java KeyStores, as written by Java's
+// JavaKeyStore and JceKeyStore.
+const (
+	JKSMagic   = 0xfeedfeed
+	JCEKSMagic = 0xcececece
+
+	JKSPrivateKeyEntry  = 1
+	JKSTrustedCertEntry = 2
+	JCEKSSecretKeyEntry = 3
+)
+
+// JKSEntry describes an entry written by EncodeJKS. Private key entries are
+// written with the given chain, trusted certificate entries with the first
+// certificate of it. The alias is written as is, so it may hold modified UTF-8.
+type JKSEntry struct {
+	Alias string
+	Tag   uint32
+	Chain []*x509.Certificate
+}
+
+// EncodeJKS writes a keystore in the layout of Java's JavaKeyStore.engineStore,
+// with a dummy encrypted key and integrity digest, which DecodeJKS ignores.
+func EncodeJKS(t *testing.T, magic uint32, entries []JKSEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	write := func(v interface{}) {
+		if err := binary.Write(&buf, binary.BigEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeUTF := func(s string) {
+		write(uint16(len(s)))
+		buf.WriteString(s)
+	}
+	writeCert := func(cert *x509.Certificate) {
+		writeUTF("X.509")
+		write(uint32(len(cert.Raw)))
+		buf.Write(cert.Raw)
+	}
+
+	write(magic)
+	write(uint32(2))
+	write(uint32(len(entries)))
+
+	for _, entry := range entries {
+		write(entry.Tag)
+		writeUTF(entry.Alias)
+		write(int64(1700000000000))
+
+		switch entry.Tag {
+		case JKSPrivateKeyEntry:
+			write(uint32(4))
+			buf.Write([]byte{0xde, 0xad, 0xbe, 0xef})
+			write(uint32(len(entry.Chain)))
+			for _, cert := range entry.Chain {
+				writeCert(cert)
+			}
+		case JKSTrustedCertEntry:
+			writeCert(entry.Chain[0])
+		case JCEKSSecretKeyEntry:
+			buf.Write([]byte{0xac, 0xed, 0x00, 0x05})
+		}
+	}
+
+	buf.Write(make([]byte, 20))
+
+	return buf.Bytes()
+}
+
+// EncodeTrustStoreJKS writes a JKS trust store holding one trusted certificate
+// entry per alias, in lexical order of the aliases.
+func EncodeTrustStoreJKS(t *testing.T, certs map[string]*x509.Certificate) []byte {
+	t.Helper()
+
+	var aliases []string
+	for alias := range certs {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	var entries []JKSEntry
+	for _, alias := range aliases {
+		entries = append(entries, JKSEntry{Alias: alias, Tag: JKSTrustedCertEntry, Chain: []*x509.Certificate{certs[alias]}})
+	}
+
+	return EncodeJKS(t, JKSMagic, entries)
+}
@@ -0,0 +1,60 @@
+// Package keystore extracts the certificates held by binary keystore formats,
+// so the exporters can report their expiry like any other certificate.
+package keystore
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+
+	"github.com/giantswarm/microerror"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// IsPKCS12 returns true if the given data looks like a DER encoded PKCS#12
+// (.p12/.pfx) bundle, which is a SEQUENCE starting with the version 3.
+func IsPKCS12(data []byte) bool {
+	var pfx struct {
+		Version  int
+		AuthSafe asn1.RawValue
+		MacData  asn1.RawValue `asn1:"optional"`
+	}
+
+	_, err := asn1.Unmarshal(data, &pfx)
+	if err != nil {
+		return false
+	}
+
+	return pfx.Version == 3
+}
+
+// DecodePKCS12 returns every certificate held by a PKCS#12 bundle, trying each
+// of the given passwords in turn. Both keystores, holding a private key and its
+// certificate chain, and Java style trust stores, holding certificates only,
+// are supported. Callers pass an empty password to open unprotected bundles.
+func DecodePKCS12(data []byte, passwords []string) ([]*x509.Certificate, error) {
+	for _, password := range passwords {
+		certs, err := decodePKCS12(data, password)
+		if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+			continue
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return certs, nil
+	}
+
+	return nil, microerror.Maskf(incorrectPasswordError, "none of the %d passwords opens the PKCS#12 bundle", len(passwords))
+}
+
+func decodePKCS12(data []byte, password string) ([]*x509.Certificate, error) {
+	_, cert, caCerts, err := pkcs12.DecodeChain(data, password)
+	if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+		return nil, err
+	} else if err == nil {
+		return append([]*x509.Certificate{cert}, caCerts...), nil
+	}
+
+	// Trust stores carry no private key, which DecodeChain refuses.
+	return pkcs12.DecodeTrustStore(data, password)
+}
@@ -0,0 +1,104 @@
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"math/big"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func generateSelfSignedCert(t *testing.T, serial int64) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+func TestDecodePKCS12_Keystore(t *testing.T) {
+	leaf, key := generateSelfSignedCert(t, 1)
+	ca, _ := generateSelfSignedCert(t, 2)
+
+	data, err := pkcs12.Modern.Encode(key, leaf, []*x509.Certificate{ca}, "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsPKCS12(data) {
+		t.Fatal("expected keystore to be detected as PKCS#12")
+	}
+
+	certs, err := DecodePKCS12(data, []string{"wrong", "s3cr3t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(certs) != 2 {
+		t.Fatalf("expected leaf and CA certificate, got %d certificates", len(certs))
+	}
+}
+
+func TestDecodePKCS12_TrustStore(t *testing.T) {
+	ca1, _ := generateSelfSignedCert(t, 1)
+	ca2, _ := generateSelfSignedCert(t, 2)
+
+	data, err := pkcs12.Modern.EncodeTrustStore([]*x509.Certificate{ca1, ca2}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certs, err := DecodePKCS12(data, []string{""})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(certs) != 2 {
+		t.Fatalf("expected 2 trusted certificates, got %d", len(certs))
+	}
+}
+
+func TestDecodePKCS12_IncorrectPassword(t *testing.T) {
+	leaf, key := generateSelfSignedCert(t, 1)
+
+	data, err := pkcs12.Modern.Encode(key, leaf, nil, "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = DecodePKCS12(data, []string{"", "wrong"})
+	if !IsIncorrectPassword(err) {
+		t.Fatalf("expected incorrect password error, got %v", err)
+	}
+}
+
+func TestIsPKCS12_Certificate(t *testing.T) {
+	cert, _ := generateSelfSignedCert(t, 1)
+
+	if IsPKCS12(cert.Raw) {
+		t.Fatal("expected a DER certificate not to be detected as PKCS#12")
+	}
+}
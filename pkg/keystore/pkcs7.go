@@ -0,0 +1,86 @@
+package keystore
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+
+	"github.com/giantswarm/microerror"
+)
+
+var oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+// pkcs7BlockTypes are the PEM block types PKCS#7 bundles are armored with, the
+// RFC 7468 one and the legacy one still written by some tools.
+var pkcs7BlockTypes = [2]string{"PKCS7", "PKCS #7 SIGNED DATA"}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// IsPKCS7 returns true if the given data looks like a DER encoded PKCS#7
+// (.p7b/.p7c) bundle, which is a ContentInfo holding SignedData.
+func IsPKCS7(data []byte) bool {
+	var info contentInfo
+	_, err := asn1.Unmarshal(data, &info)
+	if err != nil {
+		return false
+	}
+
+	return info.ContentType.Equal(oidSignedData)
+}
+
+// IsPKCS7Block returns true if the given PEM block holds a PKCS#7 bundle.
+func IsPKCS7Block(block *pem.Block) bool {
+	for _, blockType := range pkcs7BlockTypes {
+		if block.Type == blockType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DecodePKCS7 returns every certificate held by the certificates set of a DER
+// encoded PKCS#7 SignedData bundle. Such bundles are commonly used to ship
+// intermediate chains and carry no signature, the signer infos are ignored.
+func DecodePKCS7(data []byte) ([]*x509.Certificate, error) {
+	var info contentInfo
+	_, err := asn1.Unmarshal(data, &info)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if !info.ContentType.Equal(oidSignedData) {
+		return nil, microerror.Maskf(invalidKeystoreError, "PKCS#7 content type %s is not signed data", info.ContentType)
+	}
+
+	var signedData asn1.RawValue
+	_, err = asn1.Unmarshal(info.Content.Bytes, &signedData)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// The certificates are the only field of SignedData with the context
+	// specific tag 0, the encapsulated content info nests its own.
+	rest := signedData.Bytes
+	for len(rest) > 0 {
+		var field asn1.RawValue
+		rest, err = asn1.Unmarshal(rest, &field)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		if field.Class == asn1.ClassContextSpecific && field.Tag == 0 {
+			certs, err := x509.ParseCertificates(field.Bytes)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			return certs, nil
+		}
+	}
+
+	return nil, nil
+}
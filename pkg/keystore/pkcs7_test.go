@@ -0,0 +1,74 @@
+package keystore
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/giantswarm/cert-exporter/pkg/keystore/keystoretest"
+)
+
+func TestDecodePKCS7(t *testing.T) {
+	leaf, _ := generateSelfSignedCert(t, 1)
+	intermediate, _ := generateSelfSignedCert(t, 2)
+
+	data := keystoretest.EncodePKCS7(t, []*x509.Certificate{leaf, intermediate})
+
+	if !IsPKCS7(data) {
+		t.Fatal("expected bundle to be detected as PKCS#7")
+	}
+
+	if IsPKCS12(data) {
+		t.Fatal("expected bundle not to be detected as PKCS#12")
+	}
+
+	certs, err := DecodePKCS7(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(certs) != 2 {
+		t.Fatalf("expected 2 certificates, got %d", len(certs))
+	}
+
+	if certs[0].SerialNumber.Int64() != 1 || certs[1].SerialNumber.Int64() != 2 {
+		t.Errorf("unexpected serial numbers %s and %s", certs[0].SerialNumber, certs[1].SerialNumber)
+	}
+}
+
+func TestDecodePKCS7_Empty(t *testing.T) {
+	data := keystoretest.EncodePKCS7(t, nil)
+
+	certs, err := DecodePKCS7(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(certs) != 0 {
+		t.Errorf("expected no certificates, got %d", len(certs))
+	}
+}
+
+func TestIsPKCS7(t *testing.T) {
+	cert, _ := generateSelfSignedCert(t, 1)
+
+	if IsPKCS7(cert.Raw) {
+		t.Error("expected a DER certificate not to be detected as PKCS#7")
+	}
+
+	if IsPKCS7([]byte("-----BEGIN PKCS7-----")) {
+		t.Error("expected PEM text not to be detected as PKCS#7")
+	}
+}
+
+func TestIsPKCS7Block(t *testing.T) {
+	for blockType, expected := range map[string]bool{
+		"PKCS7":               true,
+		"PKCS #7 SIGNED DATA": true,
+		"CERTIFICATE":         false,
+	} {
+		if actual := IsPKCS7Block(&pem.Block{Type: blockType}); actual != expected {
+			t.Errorf("expected IsPKCS7Block(%q) to be %t, got %t", blockType, expected, actual)
+		}
+	}
+}
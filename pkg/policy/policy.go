@@ -0,0 +1,73 @@
+// Package policy audits certificates against a cryptographic policy, so
+// certificates issued with weak parameters are found without another scanner.
+package policy
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"time"
+)
+
+// Rules a certificate can violate, used as the rule label of the policy
+// violation metrics.
+const (
+	RuleRSAKeySize     = "rsa-key-size"
+	RuleValidityPeriod = "validity-period"
+	RuleWeakSignature  = "weak-signature"
+)
+
+// weakSignatureAlgorithms are SHA-1 and the even weaker digests before it.
+var weakSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
+	x509.MD2WithRSA:    true,
+	x509.MD5WithRSA:    true,
+	x509.SHA1WithRSA:   true,
+	x509.DSAWithSHA1:   true,
+	x509.ECDSAWithSHA1: true,
+}
+
+// Policy is what certificates are audited against. Each rule is disabled by
+// its zero value.
+type Policy struct {
+	// MaxValidity is the longest validity period allowed for leaf
+	// certificates, like the 398 days browsers accept. CAs are not checked.
+	MaxValidity time.Duration
+	// MinRSAKeySize is the smallest RSA key size allowed, in bits.
+	MinRSAKeySize int
+	// WeakSignatures forbids signatures using SHA-1 or weaker digests.
+	// Self-signed roots are not checked, their signature is never verified.
+	WeakSignatures bool
+}
+
+// Default returns the policy of the CA/Browser Forum baseline requirements.
+func Default() Policy {
+	return Policy{
+		MaxValidity:    398 * 24 * time.Hour,
+		MinRSAKeySize:  2048,
+		WeakSignatures: true,
+	}
+}
+
+// Violations returns the rules the given certificate violates, in the order
+// of the rule constants, or nil if it complies.
+func (p Policy) Violations(cert *x509.Certificate) []string {
+	var rules []string
+
+	if key, ok := cert.PublicKey.(*rsa.PublicKey); ok && p.MinRSAKeySize > 0 && key.N.BitLen() < p.MinRSAKeySize {
+		rules = append(rules, RuleRSAKeySize)
+	}
+
+	if !cert.IsCA && p.MaxValidity > 0 && cert.NotAfter.Sub(cert.NotBefore) > p.MaxValidity {
+		rules = append(rules, RuleValidityPeriod)
+	}
+
+	if p.WeakSignatures && weakSignatureAlgorithms[cert.SignatureAlgorithm] && !isSelfSignedRoot(cert) {
+		rules = append(rules, RuleWeakSignature)
+	}
+
+	return rules
+}
+
+func isSelfSignedRoot(cert *x509.Certificate) bool {
+	return cert.IsCA && bytes.Equal(cert.RawIssuer, cert.RawSubject)
+}
@@ -0,0 +1,94 @@
+package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func rsaKey(bits uint) *rsa.PublicKey {
+	return &rsa.PublicKey{N: new(big.Int).Lsh(big.NewInt(1), bits-1), E: 65537}
+}
+
+func TestViolations(t *testing.T) {
+	notBefore := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		policy   Policy
+		cert     *x509.Certificate
+		expected []string
+	}{
+		{
+			name:   "case 0: compliant leaf",
+			policy: Default(),
+			cert: &x509.Certificate{
+				NotBefore:          notBefore,
+				NotAfter:           notBefore.Add(90 * 24 * time.Hour),
+				PublicKey:          rsaKey(2048),
+				SignatureAlgorithm: x509.SHA256WithRSA,
+			},
+		},
+		{
+			name:   "case 1: leaf violating every rule",
+			policy: Default(),
+			cert: &x509.Certificate{
+				NotBefore:          notBefore,
+				NotAfter:           notBefore.Add(2 * 365 * 24 * time.Hour),
+				PublicKey:          rsaKey(1024),
+				SignatureAlgorithm: x509.SHA1WithRSA,
+			},
+			expected: []string{RuleRSAKeySize, RuleValidityPeriod, RuleWeakSignature},
+		},
+		{
+			name:   "case 2: long lived CA signed with SHA-1",
+			policy: Default(),
+			cert: &x509.Certificate{
+				IsCA:               true,
+				NotBefore:          notBefore,
+				NotAfter:           notBefore.Add(10 * 365 * 24 * time.Hour),
+				PublicKey:          &ecdsa.PublicKey{},
+				RawIssuer:          []byte("intermediate"),
+				RawSubject:         []byte("root"),
+				SignatureAlgorithm: x509.ECDSAWithSHA1,
+			},
+			expected: []string{RuleWeakSignature},
+		},
+		{
+			name:   "case 3: self-signed root signed with SHA-1",
+			policy: Default(),
+			cert: &x509.Certificate{
+				IsCA:               true,
+				NotBefore:          notBefore,
+				NotAfter:           notBefore.Add(20 * 365 * 24 * time.Hour),
+				PublicKey:          rsaKey(4096),
+				RawIssuer:          []byte("root"),
+				RawSubject:         []byte("root"),
+				SignatureAlgorithm: x509.SHA1WithRSA,
+			},
+		},
+		{
+			name:   "case 4: disabled rules",
+			policy: Policy{},
+			cert: &x509.Certificate{
+				NotBefore:          notBefore,
+				NotAfter:           notBefore.Add(2 * 365 * 24 * time.Hour),
+				PublicKey:          rsaKey(1024),
+				SignatureAlgorithm: x509.MD5WithRSA,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.policy.Violations(tc.cert)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package revocation
+
+import (
+	"github.com/giantswarm/microerror"
+)
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var ocspRequestError = &microerror.Error{
+	Kind: "ocspRequestError",
+}
+
+// IsOCSPRequest asserts ocspRequestError.
+func IsOCSPRequest(err error) bool {
+	return microerror.Cause(err) == ocspRequestError
+}
@@ -0,0 +1,261 @@
+package revocation
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/giantswarm/cert-exporter/pkg/certinfo"
+)
+
+// OCSP statuses reported for certificates.
+const (
+	StatusGood    = "good"
+	StatusRevoked = "revoked"
+	StatusUnknown = "unknown"
+)
+
+// maxResponseSize caps the OCSP responses read, which are a few KB at most.
+const maxResponseSize = 1 << 20
+
+// evictionGracePeriod is how long certificates no longer asked for by scrapes
+// are kept. It is longer than any sensible scrape interval, so statuses are
+// kept between scrapes whatever the check interval.
+const evictionGracePeriod = time.Hour
+
+// maxFailedRefreshes is how many checks in a row may fail before the last
+// status of a certificate is dropped, when its response has no NextUpdate
+// telling until when it is valid.
+const maxFailedRefreshes = 3
+
+// CheckerConfig configures a Checker.
+type CheckerConfig struct {
+	// Client sends the OCSP requests. http.DefaultClient with a timeout of
+	// 10 seconds is used if nil.
+	Client *http.Client
+	// Interval is how often certificates are checked again when their last
+	// response has no NextUpdate, or the last request failed.
+	Interval time.Duration
+	Logger   micrologger.Logger
+	// ResponderURL replaces the OCSP URL of every certificate if set, to
+	// query an internal responder instead.
+	ResponderURL string
+}
+
+// Checker queries the OCSP responders of certificates in the background, so
+// scrapes only read the responses cached so far. Responses are cached until
+// their NextUpdate.
+type Checker struct {
+	client       *http.Client
+	interval     time.Duration
+	logger       micrologger.Logger
+	responderURL string
+
+	mutex   sync.Mutex
+	entries map[string]*entry
+	wake    chan struct{}
+}
+
+// entry is a certificate tracked by the checker, keyed by its fingerprint.
+type entry struct {
+	cert   *x509.Certificate
+	issuer *x509.Certificate
+	// seen is when the certificate was last asked for. Certificates no
+	// longer asked for are dropped.
+	seen time.Time
+
+	checked    time.Time
+	failures   int
+	nextUpdate time.Time
+	status     string
+}
+
+func NewChecker(config CheckerConfig) (*Checker, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Interval <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Interval must be positive", config)
+	}
+
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	c := &Checker{
+		client:       client,
+		interval:     config.Interval,
+		logger:       config.Logger,
+		responderURL: config.ResponderURL,
+
+		entries: map[string]*entry{},
+		wake:    make(chan struct{}, 1),
+	}
+
+	return c, nil
+}
+
+// Status returns the OCSP status of the given certificate as last reported by
+// its responder, and tracks it for checking if it is not yet. The second value
+// is false if no response is known, e.g. before the first check.
+func (c *Checker) Status(cert, issuer *x509.Certificate) (string, bool) {
+	if len(cert.OCSPServer) == 0 && c.responderURL == "" {
+		return "", false
+	}
+
+	key := certinfo.Fingerprint(cert)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		e = &entry{cert: cert, issuer: issuer}
+		c.entries[key] = e
+
+		select {
+		case c.wake <- struct{}{}:
+		default:
+		}
+	}
+	e.seen = time.Now()
+
+	return e.status, e.status != ""
+}
+
+// Run checks the tracked certificates until the given context is done. It
+// wakes up every interval, and whenever a new certificate is tracked.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-c.wake:
+		}
+
+		c.check(ctx, time.Now())
+	}
+}
+
+// check queries the responders of the certificates due at the given time and
+// drops the ones no longer asked for.
+func (c *Checker) check(ctx context.Context, now time.Time) {
+	var due []*entry
+
+	c.mutex.Lock()
+	for key, e := range c.entries {
+		if now.Sub(e.seen) > evictionGracePeriod {
+			delete(c.entries, key)
+			continue
+		}
+
+		if e.due(now, c.interval) {
+			due = append(due, e)
+		}
+	}
+	c.mutex.Unlock()
+
+	for _, e := range due {
+		resp, err := c.query(ctx, e.cert, e.issuer)
+
+		c.mutex.Lock()
+		e.checked = now
+		if err != nil {
+			c.logger.Log("warning", fmt.Sprintf("could not check the OCSP status of certificate %x: %s", e.cert.SerialNumber, microerror.Mask(err)))
+			e.failures++
+			// Keep serving the last response until it is stale, or, without
+			// a NextUpdate, until it could not be refreshed several times.
+			if e.nextUpdate.IsZero() && e.failures >= maxFailedRefreshes {
+				e.status = ""
+			}
+			if !e.nextUpdate.IsZero() && now.After(e.nextUpdate) {
+				e.status = ""
+			}
+		} else {
+			e.failures = 0
+			e.status = statusOf(resp)
+			e.nextUpdate = resp.NextUpdate
+		}
+		c.mutex.Unlock()
+	}
+}
+
+// due returns true if the certificate has to be checked at the given time.
+func (e *entry) due(now time.Time, interval time.Duration) bool {
+	if e.checked.IsZero() {
+		return true
+	}
+	if e.status != "" && !e.nextUpdate.IsZero() {
+		return now.After(e.nextUpdate)
+	}
+
+	return now.Sub(e.checked) >= interval
+}
+
+// query asks the responder of the given certificate for its status. The
+// response is verified against the issuer.
+func (c *Checker) query(ctx context.Context, cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	url := c.responderURL
+	if url == "" {
+		url = cert.OCSPServer[0]
+	}
+
+	body, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+	req.Header.Set("Accept", "application/ocsp-response")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, microerror.Maskf(ocspRequestError, "responder %s returned %s", url, resp.Status)
+	}
+
+	der, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	parsed, err := ocsp.ParseResponseForCert(der, cert, issuer)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return parsed, nil
+}
+
+func statusOf(resp *ocsp.Response) string {
+	switch resp.Status {
+	case ocsp.Good:
+		return StatusGood
+	case ocsp.Revoked:
+		return StatusRevoked
+	default:
+		return StatusUnknown
+	}
+}
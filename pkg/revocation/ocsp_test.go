@@ -0,0 +1,308 @@
+package revocation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger"
+	"golang.org/x/crypto/ocsp"
+)
+
+// newResponder serves OCSP responses signed by the given CA, reporting the
+// given statuses by serial number and ocsp.Unknown for any other. Responses
+// have no NextUpdate if nextUpdate is 0. It counts the requests it answered.
+func newResponder(t *testing.T, ca *x509.Certificate, key *ecdsa.PrivateKey, nextUpdate time.Duration, statuses map[int64]int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		req, err := ocsp.ParseRequest(body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		status, ok := statuses[req.SerialNumber.Int64()]
+		if !ok {
+			status = ocsp.Unknown
+		}
+
+		template := ocsp.Response{
+			Status:       status,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now().Add(-1 * time.Minute),
+		}
+		if nextUpdate != 0 {
+			template.NextUpdate = time.Now().Add(nextUpdate)
+		}
+		if status == ocsp.Revoked {
+			template.RevokedAt = time.Now().Add(-1 * time.Hour)
+		}
+
+		resp, err := ocsp.CreateResponse(ca, ca, template, key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		_, _ = w.Write(resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func issueLeaf(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, serial int64, ocspServer string) *x509.Certificate {
+	t.Helper()
+
+	_, key := generateCA(t, "unused")
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "leaf"},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	if ocspServer != "" {
+		template.OCSPServer = []string{ocspServer}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+func newTestChecker(t *testing.T, responderURL string) *Checker {
+	t.Helper()
+
+	logger, err := micrologger.New(micrologger.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewChecker(CheckerConfig{
+		Interval:     time.Hour,
+		Logger:       logger,
+		ResponderURL: responderURL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestChecker_Status(t *testing.T) {
+	ca, key := generateCA(t, "ca")
+	srv, _ := newResponder(t, ca, key, time.Hour, map[int64]int{2: ocsp.Good, 3: ocsp.Revoked})
+
+	good := issueLeaf(t, ca, key, 2, srv.URL)
+	revoked := issueLeaf(t, ca, key, 3, srv.URL)
+	unknown := issueLeaf(t, ca, key, 4, srv.URL)
+	withoutURL := issueLeaf(t, ca, key, 5, "")
+
+	c := newTestChecker(t, "")
+
+	for _, cert := range []*x509.Certificate{good, revoked, unknown, withoutURL} {
+		if _, ok := c.Status(cert, ca); ok {
+			t.Fatalf("expected no status for certificate %d before the first check", cert.SerialNumber)
+		}
+	}
+
+	c.check(context.Background(), time.Now())
+
+	testCases := []struct {
+		name     string
+		cert     *x509.Certificate
+		expected string
+	}{
+		{name: "case 0: good", cert: good, expected: StatusGood},
+		{name: "case 1: revoked", cert: revoked, expected: StatusRevoked},
+		{name: "case 2: unknown", cert: unknown, expected: StatusUnknown},
+		{name: "case 3: without OCSP URL", cert: withoutURL, expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, ok := c.Status(tc.cert, ca)
+			if status != tc.expected || ok != (tc.expected != "") {
+				t.Fatalf("expected status %q, got %q (%t)", tc.expected, status, ok)
+			}
+		})
+	}
+}
+
+// TestChecker_Cache makes sure responses are served from the cache until
+// their NextUpdate, and kept when the responder fails before that.
+func TestChecker_Cache(t *testing.T) {
+	ca, key := generateCA(t, "ca")
+	srv, requests := newResponder(t, ca, key, time.Hour, map[int64]int{2: ocsp.Good})
+
+	cert := issueLeaf(t, ca, key, 2, srv.URL)
+
+	c := newTestChecker(t, "")
+	c.Status(cert, ca)
+
+	now := time.Now()
+	c.check(context.Background(), now)
+	c.check(context.Background(), now.Add(30*time.Minute))
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("expected the cached response to be used, got %d requests", got)
+	}
+
+	srv.Close()
+
+	// Past the interval but not the NextUpdate: not due.
+	c.Status(cert, ca)
+	c.check(context.Background(), now.Add(59*time.Minute))
+	if status, _ := c.Status(cert, ca); status != StatusGood {
+		t.Fatalf("expected the cached status to be served, got %q", status)
+	}
+
+	// Past the NextUpdate with the responder down: the status is dropped.
+	c.check(context.Background(), now.Add(61*time.Minute))
+	if _, ok := c.Status(cert, ca); ok {
+		t.Fatal("expected the stale status to be dropped")
+	}
+}
+
+// TestChecker_FailedRefreshes makes sure a response without NextUpdate is
+// dropped once it could not be refreshed several times in a row.
+func TestChecker_FailedRefreshes(t *testing.T) {
+	ca, key := generateCA(t, "ca")
+	srv, _ := newResponder(t, ca, key, 0, map[int64]int{2: ocsp.Good})
+
+	cert := issueLeaf(t, ca, key, 2, srv.URL)
+
+	c := newTestChecker(t, "")
+	c.interval = time.Minute
+	c.Status(cert, ca)
+
+	now := time.Now()
+	c.check(context.Background(), now)
+	if status, _ := c.Status(cert, ca); status != StatusGood {
+		t.Fatalf("expected status %q, got %q", StatusGood, status)
+	}
+
+	srv.Close()
+
+	for i := 1; i < maxFailedRefreshes; i++ {
+		c.check(context.Background(), now.Add(time.Duration(i)*c.interval))
+		if status, _ := c.Status(cert, ca); status != StatusGood {
+			t.Fatalf("expected the last status to be served after %d failed refreshes, got %q", i, status)
+		}
+	}
+
+	c.check(context.Background(), now.Add(time.Duration(maxFailedRefreshes)*c.interval))
+	if _, ok := c.Status(cert, ca); ok {
+		t.Fatal("expected the status to be dropped after repeated failed refreshes")
+	}
+}
+
+// TestChecker_Eviction makes sure certificates are kept between scrapes even
+// with a check interval much shorter than the scrape interval, and dropped
+// once no longer asked for.
+func TestChecker_Eviction(t *testing.T) {
+	ca, key := generateCA(t, "ca")
+	srv, _ := newResponder(t, ca, key, time.Hour, map[int64]int{2: ocsp.Good})
+
+	cert := issueLeaf(t, ca, key, 2, srv.URL)
+
+	c := newTestChecker(t, "")
+	c.interval = time.Second
+	c.Status(cert, ca)
+
+	now := time.Now()
+	c.check(context.Background(), now)
+	c.check(context.Background(), now.Add(5*time.Minute))
+	if status, _ := c.Status(cert, ca); status != StatusGood {
+		t.Fatalf("expected the status to be kept between scrapes, got %q", status)
+	}
+
+	c.check(context.Background(), time.Now().Add(evictionGracePeriod+time.Minute))
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.entries) != 0 {
+		t.Fatalf("expected the certificate no longer asked for to be dropped, got %d entries", len(c.entries))
+	}
+}
+
+// TestChecker_ResponderURL makes sure the configured responder is queried
+// instead of the one of the certificate.
+func TestChecker_ResponderURL(t *testing.T) {
+	ca, key := generateCA(t, "ca")
+	srv, requests := newResponder(t, ca, key, time.Hour, map[int64]int{2: ocsp.Revoked})
+
+	cert := issueLeaf(t, ca, key, 2, "http://127.0.0.1:1/unreachable")
+
+	c := newTestChecker(t, srv.URL)
+	c.Status(cert, ca)
+	c.check(context.Background(), time.Now())
+
+	if status, _ := c.Status(cert, ca); status != StatusRevoked {
+		t.Fatalf("expected status %q, got %q", StatusRevoked, status)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("expected 1 request to the configured responder, got %d", got)
+	}
+}
+
+func TestChecker_Run(t *testing.T) {
+	ca, key := generateCA(t, "ca")
+	srv, _ := newResponder(t, ca, key, time.Hour, map[int64]int{2: ocsp.Good})
+
+	cert := issueLeaf(t, ca, key, 2, srv.URL)
+
+	c := newTestChecker(t, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	// Tracking a new certificate wakes the checker up.
+	c.Status(cert, ca)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if status, _ := c.Status(cert, ca); status == StatusGood {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the certificate to be checked")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewChecker(t *testing.T) {
+	_, err := NewChecker(CheckerConfig{Interval: time.Hour})
+	if !IsInvalidConfig(err) {
+		t.Fatalf("expected an invalid config error without logger, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+// Package revocation tells whether certificates were revoked by the
+// certificate revocation lists of their issuer.
+package revocation
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/giantswarm/microerror"
+)
+
+// BlockType is the PEM block type of certificate revocation lists.
+const BlockType = "X509 CRL"
+
+// ParseCRLs returns the certificate revocation lists held by the given data,
+// either as X509 CRL blocks among other PEM blocks or as a single binary DER
+// CRL. Other data holds none. Blocks failing to parse are skipped and the
+// first error is returned along with the other CRLs.
+func ParseCRLs(data []byte) ([]*x509.RevocationList, error) {
+	if crl, err := x509.ParseRevocationList(data); err == nil {
+		return []*x509.RevocationList{crl}, nil
+	}
+
+	var crls []*x509.RevocationList
+	var firstErr error
+	rest := data
+	for {
+		block, remaining := pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		rest = remaining
+
+		if block.Type != BlockType {
+			continue
+		}
+
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = microerror.Mask(err)
+			}
+			continue
+		}
+
+		crls = append(crls, crl)
+	}
+
+	return crls, firstErr
+}
+
+// Index holds the serial numbers revoked by a set of CRLs, per issuer.
+type Index struct {
+	revoked map[string]map[string]bool
+}
+
+// NewIndex indexes the serial numbers revoked by the given CRLs. CRLs of the
+// same issuer are merged, so a certificate revoked by an older CRL stays
+// revoked.
+func NewIndex(crls []*x509.RevocationList) Index {
+	index := Index{
+		revoked: map[string]map[string]bool{},
+	}
+
+	for _, crl := range crls {
+		issuer := string(crl.RawIssuer)
+		if index.revoked[issuer] == nil {
+			index.revoked[issuer] = map[string]bool{}
+		}
+
+		for _, entry := range crl.RevokedCertificateEntries {
+			index.revoked[issuer][entry.SerialNumber.String()] = true
+		}
+	}
+
+	return index
+}
+
+// Status returns whether the given certificate is revoked. The second value
+// is false if no CRL of its issuer is known, in which case its revocation
+// status is unknown.
+func (i Index) Status(cert *x509.Certificate) (bool, bool) {
+	revoked, ok := i.revoked[string(cert.RawIssuer)]
+	if !ok {
+		return false, false
+	}
+
+	return revoked[cert.SerialNumber.String()], true
+}
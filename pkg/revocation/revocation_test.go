@@ -0,0 +1,167 @@
+package revocation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func generateCA(t *testing.T, cn string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-1 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+func generateCRL(t *testing.T, ca *x509.Certificate, key *ecdsa.PrivateKey, revoked ...int64) []byte {
+	t.Helper()
+
+	template := &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-1 * time.Hour),
+		NextUpdate: time.Now().Add(24 * time.Hour),
+	}
+	for _, serial := range revoked {
+		template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: time.Now().Add(-1 * time.Hour),
+		})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, template, ca, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return der
+}
+
+func TestParseCRLs(t *testing.T) {
+	ca, key := generateCA(t, "ca")
+	crl := generateCRL(t, ca, key, 2)
+
+	testCases := []struct {
+		name     string
+		data     []byte
+		expected int
+		err      bool
+	}{
+		{
+			name:     "case 0: DER",
+			data:     crl,
+			expected: 1,
+		},
+		{
+			name:     "case 1: PEM bundled with a certificate",
+			data:     append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), pem.EncodeToMemory(&pem.Block{Type: BlockType, Bytes: crl})...),
+			expected: 1,
+		},
+		{
+			name:     "case 2: certificate only",
+			data:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}),
+			expected: 0,
+		},
+		{
+			name:     "case 3: broken block next to a valid one",
+			data:     append(pem.EncodeToMemory(&pem.Block{Type: BlockType, Bytes: []byte("broken")}), pem.EncodeToMemory(&pem.Block{Type: BlockType, Bytes: crl})...),
+			expected: 1,
+			err:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			crls, err := ParseCRLs(tc.data)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error %t, got %v", tc.err, err)
+			}
+			if len(crls) != tc.expected {
+				t.Fatalf("expected %d CRLs, got %d", tc.expected, len(crls))
+			}
+		})
+	}
+}
+
+func TestIndex_Status(t *testing.T) {
+	ca, key := generateCA(t, "ca")
+	other, _ := generateCA(t, "other")
+
+	var crls []*x509.RevocationList
+	for _, der := range [][]byte{generateCRL(t, ca, key, 2), generateCRL(t, ca, key, 3)} {
+		crl, err := x509.ParseRevocationList(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		crls = append(crls, crl)
+	}
+
+	index := NewIndex(crls)
+
+	testCases := []struct {
+		name    string
+		cert    *x509.Certificate
+		revoked bool
+		known   bool
+	}{
+		{
+			name:    "case 0: revoked by the first CRL",
+			cert:    &x509.Certificate{RawIssuer: ca.RawSubject, SerialNumber: big.NewInt(2)},
+			revoked: true,
+			known:   true,
+		},
+		{
+			name:    "case 1: revoked by the second CRL",
+			cert:    &x509.Certificate{RawIssuer: ca.RawSubject, SerialNumber: big.NewInt(3)},
+			revoked: true,
+			known:   true,
+		},
+		{
+			name:  "case 2: not revoked",
+			cert:  &x509.Certificate{RawIssuer: ca.RawSubject, SerialNumber: big.NewInt(4)},
+			known: true,
+		},
+		{
+			name: "case 3: issuer without CRL",
+			cert: &x509.Certificate{RawIssuer: other.RawSubject, SerialNumber: big.NewInt(2)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			revoked, known := index.Status(tc.cert)
+			if revoked != tc.revoked || known != tc.known {
+				t.Fatalf("expected revoked %t and known %t, got %t and %t", tc.revoked, tc.known, revoked, known)
+			}
+		})
+	}
+}
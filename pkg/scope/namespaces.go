@@ -0,0 +1,102 @@
+package scope
+
+import (
+	"slices"
+
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type NamespacesConfig struct {
+	// Client watches the namespaces matching LabelSelector. It is only
+	// required with a LabelSelector.
+	Client kubernetes.Interface
+
+	// Excluded are the namespaces never reported on, whatever the other
+	// fields select.
+	Excluded []string
+	// LabelSelector selects namespaces by label, like tenant=true. Namespaces
+	// created or labelled later are picked up without a restart.
+	LabelSelector string
+	// Namespaces are the only namespaces reported on, all if empty.
+	Namespaces []string
+}
+
+// Namespaces decides which namespaces the secret and cr exporters report on,
+// so both apply the same namespace flags the same way.
+type Namespaces struct {
+	informer coreinformers.NamespaceInformer
+
+	excluded   []string
+	namespaces []string
+}
+
+func NewNamespaces(config NamespacesConfig) (*Namespaces, error) {
+	n := &Namespaces{
+		excluded:   config.Excluded,
+		namespaces: config.Namespaces,
+	}
+
+	if config.LabelSelector != "" {
+		_, err := labels.Parse(config.LabelSelector)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "namespace label selector %#q: %s", config.LabelSelector, err)
+		}
+		if config.Client == nil {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Client must not be empty with a label selector", config)
+		}
+
+		factory := informers.NewSharedInformerFactoryWithOptions(config.Client, 0,
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.LabelSelector = config.LabelSelector
+			}),
+		)
+		n.informer = factory.Core().V1().Namespaces()
+	}
+
+	return n, nil
+}
+
+// Start watches the namespaces matching the label selector until stop is
+// closed. It does nothing without a label selector.
+func (n *Namespaces) Start(stop <-chan struct{}) {
+	if n.informer != nil {
+		go n.informer.Informer().Run(stop)
+	}
+}
+
+// Watch returns the namespaces to list or watch objects in, all namespaces
+// if the returned list is empty. Objects found there still have to be checked
+// with Contains.
+func (n *Namespaces) Watch() []string {
+	return n.namespaces
+}
+
+// Contains returns whether objects of the given namespace are reported on.
+// Until the namespaces matching the label selector are synced, none are.
+func (n *Namespaces) Contains(namespace string) bool {
+	if slices.Contains(n.excluded, namespace) {
+		return false
+	}
+
+	if len(n.namespaces) > 0 && !slices.Contains(n.namespaces, namespace) {
+		return false
+	}
+
+	if n.informer != nil {
+		if !n.informer.Informer().HasSynced() {
+			return false
+		}
+
+		_, err := n.informer.Lister().Get(namespace)
+		if err != nil {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,135 @@
+package scope
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newNamespace(name string, labels map[string]string) *v1.Namespace {
+	return &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestNamespaces_Contains(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    NamespacesConfig
+		namespace string
+		expected  bool
+	}{
+		{
+			name:      "case 0: all namespaces",
+			namespace: "default",
+			expected:  true,
+		},
+		{
+			name:      "case 1: listed namespace",
+			config:    NamespacesConfig{Namespaces: []string{"default", "monitoring"}},
+			namespace: "monitoring",
+			expected:  true,
+		},
+		{
+			name:      "case 2: unlisted namespace",
+			config:    NamespacesConfig{Namespaces: []string{"default"}},
+			namespace: "monitoring",
+			expected:  false,
+		},
+		{
+			name:      "case 3: excluded namespace",
+			config:    NamespacesConfig{Excluded: []string{"kube-system"}},
+			namespace: "kube-system",
+			expected:  false,
+		},
+		{
+			name:      "case 4: listed but excluded namespace",
+			config:    NamespacesConfig{Excluded: []string{"kube-system"}, Namespaces: []string{"kube-system"}},
+			namespace: "kube-system",
+			expected:  false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := NewNamespaces(tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := n.Contains(tc.namespace); got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestNamespaces_LabelSelector covers namespaces selected by label, including
+// one labelled after the exporter started.
+func TestNamespaces_LabelSelector(t *testing.T) {
+	client := fake.NewClientset(
+		newNamespace("tenant-a", map[string]string{"tenant": "true"}),
+		newNamespace("tenant-test", map[string]string{"tenant": "true"}),
+		newNamespace("default", nil),
+	)
+
+	n, err := NewNamespaces(NamespacesConfig{
+		Client:        client,
+		Excluded:      []string{"tenant-test"},
+		LabelSelector: "tenant=true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n.Contains("tenant-a") {
+		t.Fatal("expected no namespace to be contained before syncing")
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	n.Start(stop)
+	waitFor(t, func() bool { return n.Contains("tenant-a") })
+
+	if n.Contains("tenant-test") {
+		t.Error("expected the excluded namespace not to be contained")
+	}
+	if n.Contains("default") {
+		t.Error("expected the unlabelled namespace not to be contained")
+	}
+
+	_, err = client.CoreV1().Namespaces().Create(context.Background(), newNamespace("tenant-b", map[string]string{"tenant": "true"}), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return n.Contains("tenant-b") })
+}
+
+func TestNewNamespaces_Invalid(t *testing.T) {
+	_, err := NewNamespaces(NamespacesConfig{Client: fake.NewClientset(), LabelSelector: "tenant in ("})
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected a malformed label selector to be invalid, got %v", err)
+	}
+
+	_, err = NewNamespaces(NamespacesConfig{LabelSelector: "tenant=true"})
+	if !IsInvalidConfig(err) {
+		t.Errorf("expected a label selector without client to be invalid, got %v", err)
+	}
+}
+
+// waitFor polls the given condition until it holds, failing the test after a
+// few seconds.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Fatal("condition not met in time")
+}
@@ -0,0 +1,16 @@
+// Package scope decides which namespaces and Kubernetes objects the secret and
+// cr exporters report on.
+package scope
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IgnoreAnnotation excludes the secret or Certificate it is set to "true" on,
+// so teams can silence test fixtures without changing the exporter flags.
+const IgnoreAnnotation = "cert-exporter.giantswarm.io/ignore"
+
+// Ignored returns whether the given object opted out with IgnoreAnnotation.
+func Ignored(object metav1.Object) bool {
+	return object.GetAnnotations()[IgnoreAnnotation] == "true"
+}
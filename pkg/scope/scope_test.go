@@ -0,0 +1,40 @@
+package scope
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIgnored(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    bool
+	}{
+		{
+			name:     "case 0: no annotations",
+			expected: false,
+		},
+		{
+			name:        "case 1: ignored",
+			annotations: map[string]string{IgnoreAnnotation: "true"},
+			expected:    true,
+		},
+		{
+			name:        "case 2: explicitly not ignored",
+			annotations: map[string]string{IgnoreAnnotation: "false"},
+			expected:    false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			if got := Ignored(secret); got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
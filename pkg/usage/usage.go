@@ -0,0 +1,130 @@
+// Package usage checks the key usage and extended key usage of certificates
+// against the role they are used in, which expiry checks do not catch but
+// handshakes fail on.
+package usage
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"path/filepath"
+	"strings"
+)
+
+// Roles a certificate is used in.
+const (
+	RoleCA     = "ca"
+	RoleClient = "client"
+	RolePeer   = "peer"
+	RoleServer = "server"
+)
+
+// Usages a certificate can miss, named like in RFC 5280.
+const (
+	UsageClientAuth       = "clientAuth"
+	UsageDigitalSignature = "digitalSignature"
+	UsageKeyCertSign      = "keyCertSign"
+	UsageServerAuth       = "serverAuth"
+)
+
+// roleTokens map the tokens of a file name or secret key to the role they
+// imply, checked in order so apiserver-etcd-client.crt is a client.
+var roleTokens = []struct {
+	role   string
+	tokens []string
+}{
+	{role: RoleCA, tokens: []string{"ca"}},
+	{role: RolePeer, tokens: []string{"peer"}},
+	{role: RoleClient, tokens: []string{"client"}},
+	{role: RoleServer, tokens: []string{"apiserver", "server", "serving", "tls"}},
+}
+
+// RoleOf returns the role implied by the name of the file or secret key a
+// certificate was found in, like ca.crt, tls.crt, peer.crt or
+// apiserver-kubelet-client.crt. It is empty if the name implies none.
+func RoleOf(name string) string {
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	tokens := strings.FieldsFunc(strings.ToLower(base), func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	})
+
+	for _, rt := range roleTokens {
+		for _, token := range tokens {
+			for _, t := range rt.tokens {
+				if token == t {
+					return rt.role
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// Missing returns the usages the given certificate lacks for the given role,
+// in the order of the usage constants. Certificates without key usage or
+// extended key usage extensions are valid for any usage, so only the usages
+// of extensions that are present are checked.
+func Missing(cert *x509.Certificate, role string) []string {
+	var missing []string
+
+	switch role {
+	case RoleCA:
+		if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+			missing = append(missing, UsageKeyCertSign)
+		}
+	case RoleClient, RolePeer, RoleServer:
+		if (role == RoleClient || role == RolePeer) && !hasExtKeyUsage(cert, x509.ExtKeyUsageClientAuth) {
+			missing = append(missing, UsageClientAuth)
+		}
+		if cert.KeyUsage != 0 && cert.KeyUsage&signatureUsages(cert) == 0 {
+			missing = append(missing, UsageDigitalSignature)
+		}
+		if (role == RoleServer || role == RolePeer) && !hasExtKeyUsage(cert, x509.ExtKeyUsageServerAuth) {
+			missing = append(missing, UsageServerAuth)
+		}
+	}
+
+	return missing
+}
+
+// signatureUsages returns the key usages letting the given leaf take part in
+// a handshake. RSA key exchange only needs key encipherment, every other key
+// exchange needs digital signature.
+func signatureUsages(cert *x509.Certificate) x509.KeyUsage {
+	if _, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+		return x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	}
+
+	return x509.KeyUsageDigitalSignature
+}
+
+func hasExtKeyUsage(cert *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	if len(cert.ExtKeyUsage) == 0 && len(cert.UnknownExtKeyUsage) == 0 {
+		return true
+	}
+
+	for _, u := range cert.ExtKeyUsage {
+		if u == usage || u == x509.ExtKeyUsageAny {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RoleFor returns the role of the given certificate found in a location
+// implying the given role. CA certificates bundled with a leaf, like the
+// intermediates of a tls.crt, are CAs whatever their location implies. Leaves
+// found where a CA is expected, like the self-signed leaf cert-manager copies
+// into ca.crt, have no role, as nothing tells which one they are used in.
+func RoleFor(cert *x509.Certificate, locationRole string) string {
+	if cert.IsCA {
+		return RoleCA
+	}
+
+	if locationRole == RoleCA {
+		return ""
+	}
+
+	return locationRole
+}
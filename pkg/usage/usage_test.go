@@ -0,0 +1,119 @@
+package usage
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"reflect"
+	"testing"
+)
+
+func TestRoleOf(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "/etc/kubernetes/pki/ca.crt", expected: RoleCA},
+		{name: "/etc/kubernetes/pki/front-proxy-ca.crt", expected: RoleCA},
+		{name: "/etc/kubernetes/pki/etcd/peer.crt", expected: RolePeer},
+		{name: "/etc/kubernetes/pki/apiserver-etcd-client.crt", expected: RoleClient},
+		{name: "/var/lib/kubelet/pki/kubelet-client-current.pem", expected: RoleClient},
+		{name: "/etc/kubernetes/pki/apiserver.crt", expected: RoleServer},
+		{name: "tls.crt", expected: RoleServer},
+		{name: "/etc/kubernetes/pki/sa.pub", expected: ""},
+		{name: "/etc/ssl/certs/cacert.pem", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := RoleOf(tc.name); actual != tc.expected {
+				t.Errorf("expected role %#q, got %#q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMissing(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cert     *x509.Certificate
+		role     string
+		expected []string
+	}{
+		{
+			name: "case 0: CA with keyCertSign",
+			cert: &x509.Certificate{IsCA: true, KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign},
+			role: RoleCA,
+		},
+		{
+			name:     "case 1: CA without keyCertSign",
+			cert:     &x509.Certificate{IsCA: true, KeyUsage: x509.KeyUsageCRLSign},
+			role:     RoleCA,
+			expected: []string{UsageKeyCertSign},
+		},
+		{
+			name: "case 2: CA without key usage extension",
+			cert: &x509.Certificate{IsCA: true},
+			role: RoleCA,
+		},
+		{
+			name: "case 3: server with serverAuth",
+			cert: &x509.Certificate{KeyUsage: x509.KeyUsageDigitalSignature, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}},
+			role: RoleServer,
+		},
+		{
+			name:     "case 4: server with clientAuth only",
+			cert:     &x509.Certificate{KeyUsage: x509.KeyUsageDigitalSignature, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}},
+			role:     RoleServer,
+			expected: []string{UsageServerAuth},
+		},
+		{
+			name: "case 5: RSA server without extended key usage extension",
+			cert: &x509.Certificate{KeyUsage: x509.KeyUsageKeyEncipherment, PublicKey: &rsa.PublicKey{}},
+			role: RoleServer,
+		},
+		{
+			name:     "case 6: peer with serverAuth only and no signing key usage",
+			cert:     &x509.Certificate{KeyUsage: x509.KeyUsageCertSign, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}},
+			role:     RolePeer,
+			expected: []string{UsageClientAuth, UsageDigitalSignature},
+		},
+		{
+			name: "case 7: client with any extended key usage",
+			cert: &x509.Certificate{ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}},
+			role: RoleClient,
+		},
+		{
+			name: "case 8: no role",
+			cert: &x509.Certificate{KeyUsage: x509.KeyUsageCRLSign},
+			role: "",
+		},
+		{
+			name:     "case 9: ECDSA server with keyEncipherment only",
+			cert:     &x509.Certificate{KeyUsage: x509.KeyUsageKeyEncipherment, PublicKey: &ecdsa.PublicKey{}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}},
+			role:     RoleServer,
+			expected: []string{UsageDigitalSignature},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := Missing(tc.cert, tc.role)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRoleFor(t *testing.T) {
+	if role := RoleFor(&x509.Certificate{IsCA: true}, RoleServer); role != RoleCA {
+		t.Errorf("expected an intermediate in tls.crt to be a CA, got %#q", role)
+	}
+	if role := RoleFor(&x509.Certificate{}, RoleServer); role != RoleServer {
+		t.Errorf("expected a leaf in tls.crt to be a server, got %#q", role)
+	}
+	if role := RoleFor(&x509.Certificate{KeyUsage: x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment}, RoleCA); role != "" {
+		t.Errorf("expected a self-signed leaf in ca.crt to have no role, got %#q", role)
+	}
+}